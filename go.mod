@@ -7,6 +7,8 @@ require (
 	github.com/rs/zerolog v1.33.0
 	github.com/txthinking/socks5 v0.0.0-20230325130024-4230056ae301
 	github.com/zhouchenh/go-descriptor v1.1.0
+	golang.org/x/net v0.33.0
+	golang.org/x/sys v0.28.0
 )
 
 require (
@@ -15,8 +17,6 @@ require (
 	github.com/patrickmn/go-cache v2.1.0+incompatible // indirect
 	github.com/txthinking/runnergroup v0.0.0-20241229123329-7b873ad00768 // indirect
 	golang.org/x/mod v0.22.0 // indirect
-	golang.org/x/net v0.33.0 // indirect
 	golang.org/x/sync v0.10.0 // indirect
-	golang.org/x/sys v0.28.0 // indirect
 	golang.org/x/tools v0.28.0 // indirect
 )
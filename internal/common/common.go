@@ -1,6 +1,7 @@
 package common
 
 import (
+	"errors"
 	"fmt"
 	"github.com/miekg/dns"
 	"github.com/zhouchenh/secDNS/internal/logger"
@@ -14,12 +15,56 @@ var ClientErrorMessageHandler = func(query *dns.Msg) *dns.Msg {
 	return msg
 }
 
-var ServerErrorMessageHandler = func(query *dns.Msg) *dns.Msg {
+var ServerErrorMessageHandler = func(query *dns.Msg, err error) *dns.Msg {
 	msg := new(dns.Msg)
 	msg.SetRcode(query, dns.RcodeServerFailure)
+	msg.RecursionAvailable = true
+	if infoCode, ok := extendedErrorCode(err); ok {
+		AddExtendedError(query, msg, infoCode, "")
+	}
 	return msg
 }
 
+// EDNS0UDPSize is the UDP payload size advertised in locally generated OPT
+// records.
+const EDNS0UDPSize = 1232
+
+// EnsureEDNS0 returns the OPT record of reply. If query uses EDNS and reply
+// has no OPT record, one is added with EDNS0UDPSize and the DO bit cleared,
+// since locally generated replies are never signed. It returns nil if query
+// does not use EDNS.
+func EnsureEDNS0(query *dns.Msg, reply *dns.Msg) *dns.OPT {
+	if query.IsEdns0() == nil {
+		return nil
+	}
+	if opt := reply.IsEdns0(); opt != nil {
+		return opt
+	}
+	reply.SetEdns0(EDNS0UDPSize, false)
+	return reply.IsEdns0()
+}
+
+// AddExtendedError adds an extended DNS error (RFC 8914) to reply, if query
+// uses EDNS.
+func AddExtendedError(query *dns.Msg, reply *dns.Msg, infoCode uint16, extraText string) {
+	opt := EnsureEDNS0(query, reply)
+	if opt == nil {
+		return
+	}
+	opt.Option = append(opt.Option, &dns.EDNS0_EDE{InfoCode: infoCode, ExtraText: extraText})
+}
+
+func extendedErrorCode(err error) (infoCode uint16, ok bool) {
+	var netErr net.Error
+	if !errors.As(err, &netErr) {
+		return
+	}
+	if netErr.Timeout() {
+		return dns.ExtendedErrorCodeNoReachableAuthority, true
+	}
+	return dns.ExtendedErrorCodeNetworkError, true
+}
+
 var ErrOutputErrorHandler = func(err error) {
 	ErrOutput(err)
 }
@@ -29,7 +74,11 @@ func Output(a ...interface{}) {
 }
 
 func ErrOutput(a ...interface{}) {
-	logger.Error().Msg(fmt.Sprint(a...))
+	msg := fmt.Sprint(a...)
+	if !logger.Sample(msg) {
+		return
+	}
+	logger.Error().Msg(msg)
 }
 
 func ParseIPv4v6(str string) (ip net.IP) {
@@ -111,3 +160,77 @@ func FilterResourceRecords(records []dns.RR, predicate func(rr dns.RR) bool) (re
 	}
 	return
 }
+
+// NegativeSOA returns a copy of soa for the authority section of a negative
+// response, with its TTL lowered to the SOA MINIMUM field if that is smaller,
+// as described in RFC 2308 section 3.
+func NegativeSOA(soa *dns.SOA) *dns.SOA {
+	negative := dns.Copy(soa).(*dns.SOA)
+	if negative.Minttl < negative.Hdr.Ttl {
+		negative.Hdr.Ttl = negative.Minttl
+	}
+	return negative
+}
+
+// SyntheticSOA returns an SOA record owned by name, for negative responses to
+// names which do not belong to any known zone. Its TTL and timers are all set
+// to ttl, so that the response is cached negatively for ttl seconds.
+func SyntheticSOA(name string, ttl uint32) *dns.SOA {
+	return &dns.SOA{
+		Hdr:     dns.RR_Header{Name: name, Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: ttl},
+		Ns:      name,
+		Mbox:    name,
+		Serial:  1,
+		Refresh: ttl,
+		Retry:   ttl,
+		Expire:  ttl,
+		Minttl:  ttl,
+	}
+}
+
+func FilterEDNS0Options(options []dns.EDNS0, predicate func(option dns.EDNS0) bool) (result []dns.EDNS0) {
+	for _, option := range options {
+		if predicate(option) {
+			result = append(result, option)
+		}
+	}
+	return
+}
+
+func SelectEDNS0Options(msg *dns.Msg, strip []uint16, keepOnly []uint16) *dns.Msg {
+	opt := msg.IsEdns0()
+	if opt == nil || (len(strip) < 1 && keepOnly == nil) {
+		return msg
+	}
+	contains := func(codes []uint16, code uint16) bool {
+		for _, c := range codes {
+			if c == code {
+				return true
+			}
+		}
+		return false
+	}
+	options := FilterEDNS0Options(opt.Option, func(option dns.EDNS0) bool {
+		code := option.Option()
+		return !contains(strip, code) && (keepOnly == nil || contains(keepOnly, code))
+	})
+	if len(options) == len(opt.Option) {
+		return msg
+	}
+	msg = msg.Copy()
+	msg.IsEdns0().Option = options
+	return msg
+}
+
+func QuestionMatches(query *dns.Msg, reply *dns.Msg) bool {
+	if len(query.Question) != len(reply.Question) {
+		return false
+	}
+	for i, question := range query.Question {
+		r := reply.Question[i]
+		if question.Qtype != r.Qtype || question.Qclass != r.Qclass || !strings.EqualFold(question.Name, r.Name) {
+			return false
+		}
+	}
+	return true
+}
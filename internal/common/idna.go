@@ -0,0 +1,165 @@
+package common
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	punycodeBase        = 36
+	punycodeTMin        = 1
+	punycodeTMax        = 26
+	punycodeSkew        = 38
+	punycodeDamp        = 700
+	punycodeInitialBias = 72
+	punycodeInitialN    = 128
+)
+
+// ToASCII converts every label of a domain name which contains non-ASCII
+// characters to its A-label form (RFC 5890), so that Unicode and punycode
+// names can be matched against each other. The name may be quoted, and may
+// contain \DDD escapes as produced by the dns package. Labels are lower-cased
+// before encoding; the full IDNA2008 mapping and validation is not performed.
+func ToASCII(name string) string {
+	if hasPrefixAndSuffix(name, "\"", "\"") {
+		return "\"" + toASCII(trimPrefixAndSuffix(name, "\"", "\"")) + "\""
+	}
+	return toASCII(name)
+}
+
+func toASCII(name string) string {
+	labels := strings.Split(name, ".")
+	changed := false
+	for index, label := range labels {
+		decoded := unescapeLabel(label)
+		if !needsEncoding(decoded) {
+			continue
+		}
+		labels[index] = "xn--" + punycodeEncode([]rune(strings.ToLower(decoded)))
+		changed = true
+	}
+	if !changed {
+		return name
+	}
+	return strings.Join(labels, ".")
+}
+
+func needsEncoding(label string) bool {
+	if !utf8.ValidString(label) {
+		return false
+	}
+	for i := 0; i < len(label); i++ {
+		if label[i] >= utf8.RuneSelf {
+			return true
+		}
+	}
+	return false
+}
+
+func unescapeLabel(label string) string {
+	if !strings.Contains(label, "\\") {
+		return label
+	}
+	builder := strings.Builder{}
+	for i := 0; i < len(label); i++ {
+		if label[i] != '\\' || i+1 >= len(label) {
+			builder.WriteByte(label[i])
+			continue
+		}
+		if i+3 < len(label) && isDigit(label[i+1]) && isDigit(label[i+2]) && isDigit(label[i+3]) {
+			value := int(label[i+1]-'0')*100 + int(label[i+2]-'0')*10 + int(label[i+3]-'0')
+			if value <= 255 {
+				builder.WriteByte(byte(value))
+				i += 3
+				continue
+			}
+		}
+		// Keep other escapes, such as "\.", as they are, so that they are not
+		// mistaken for label separators.
+		builder.WriteByte(label[i])
+		builder.WriteByte(label[i+1])
+		i++
+	}
+	return builder.String()
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+// punycodeEncode implements the encoding procedure of RFC 3492 section 6.3.
+func punycodeEncode(input []rune) string {
+	builder := strings.Builder{}
+	for _, r := range input {
+		if r < utf8.RuneSelf {
+			builder.WriteRune(r)
+		}
+	}
+	basic := builder.Len()
+	handled := basic
+	if basic > 0 {
+		builder.WriteByte('-')
+	}
+	n, delta, bias := rune(punycodeInitialN), 0, punycodeInitialBias
+	for handled < len(input) {
+		m := rune(utf8.MaxRune)
+		for _, r := range input {
+			if r >= n && r < m {
+				m = r
+			}
+		}
+		delta += int(m-n) * (handled + 1)
+		n = m
+		for _, r := range input {
+			if r < n {
+				delta++
+			}
+			if r != n {
+				continue
+			}
+			q := delta
+			for k := punycodeBase; ; k += punycodeBase {
+				t := k - bias
+				if t < punycodeTMin {
+					t = punycodeTMin
+				} else if t > punycodeTMax {
+					t = punycodeTMax
+				}
+				if q < t {
+					break
+				}
+				builder.WriteByte(punycodeDigit(t + (q-t)%(punycodeBase-t)))
+				q = (q - t) / (punycodeBase - t)
+			}
+			builder.WriteByte(punycodeDigit(q))
+			bias = punycodeAdapt(delta, handled+1, handled == basic)
+			delta = 0
+			handled++
+		}
+		delta++
+		n++
+	}
+	return builder.String()
+}
+
+func punycodeAdapt(delta, numPoints int, firstTime bool) int {
+	if firstTime {
+		delta /= punycodeDamp
+	} else {
+		delta /= 2
+	}
+	delta += delta / numPoints
+	k := 0
+	for delta > ((punycodeBase-punycodeTMin)*punycodeTMax)/2 {
+		delta /= punycodeBase - punycodeTMin
+		k += punycodeBase
+	}
+	return k + (punycodeBase-punycodeTMin+1)*delta/(delta+punycodeSkew)
+}
+
+func punycodeDigit(d int) byte {
+	if d < 26 {
+		return byte('a' + d)
+	}
+	return byte('0' + d - 26)
+}
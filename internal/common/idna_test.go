@@ -0,0 +1,48 @@
+package common
+
+import "testing"
+
+func TestToASCII(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		// RFC 3492 section 7.1 sample strings.
+		{"ليهمابتكلموشعربي؟", "xn--egbpdaj6bu4bxfgehfvwxn"},
+		{"他们为什么不说中文", "xn--ihqwcrb4cv8a8dqg056pqjye"},
+		{"למההםפשוטלאמדבריםעברית", "xn--4dbcagdahymbxekheh6e0a7fei0b"},
+		{"なぜみんな日本語を話してくれないのか", "xn--n8jok5ay5dzabd5bym9f0cm5685rrjetr6pdxa"},
+		{"почемужеонинеговорятпорусски", "xn--b1abfaaepdrnnbgefbadotcwatmq2g4l"},
+		// Common IDNA examples.
+		{"münchen.de.", "xn--mnchen-3ya.de."},
+		{"bücher.example", "xn--bcher-kva.example"},
+		{"faß.de", "xn--fa-hia.de"},
+		{"пример.рф.", "xn--e1afmkfd.xn--p1ai."},
+		{"例え.テスト.", "xn--r8jz45g.xn--zckzah."},
+		// Mixed-case Unicode labels are lower-cased before encoding.
+		{"MÜNCHEN.de.", "xn--mnchen-3ya.de."},
+		{"München.DE.", "xn--mnchen-3ya.DE."},
+		// Already-ASCII names are returned unchanged.
+		{"example.com.", "example.com."},
+		{"Example.COM.", "Example.COM."},
+		{"xn--mnchen-3ya.de.", "xn--mnchen-3ya.de."},
+		// Empty labels and trailing dots are kept.
+		{"", ""},
+		{".", "."},
+		{"münchen", "xn--mnchen-3ya"},
+		{"münchen..de", "xn--mnchen-3ya..de"},
+		{"münchen.de..", "xn--mnchen-3ya.de.."},
+		// \DDD escapes as produced by the dns package.
+		{"m\\195\\188nchen.de.", "xn--mnchen-3ya.de."},
+		{"a\\.b.m\\195\\188nchen.", "a\\.b.xn--mnchen-3ya."},
+		// Labels which are not valid UTF-8 are returned unchanged.
+		{"\\255abc.de.", "\\255abc.de."},
+		// Quoted names.
+		{"\"münchen.de\"", "\"xn--mnchen-3ya.de\""},
+	}
+	for _, test := range tests {
+		if got := ToASCII(test.name); got != test.want {
+			t.Errorf("ToASCII(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package common
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+)
+
+var ErrSPKIPinMismatch = errors.New("common: No certificate matches the pinned public keys")
+
+// ParseSPKIPin decodes a base64 encoded SHA-256 digest of a
+// SubjectPublicKeyInfo, as used by HTTP Public Key Pinning (RFC 7469).
+func ParseSPKIPin(str string) (pin []byte, ok bool) {
+	pin, err := base64.StdEncoding.DecodeString(str)
+	if err != nil || len(pin) != sha256.Size {
+		return nil, false
+	}
+	return pin, true
+}
+
+// VerifySPKIPins returns a tls.Config VerifyConnection function which rejects
+// connections unless the public key of a certificate presented by the peer
+// matches one of pins. It returns nil if pins is empty.
+func VerifySPKIPins(pins [][]byte) func(state tls.ConnectionState) error {
+	if len(pins) < 1 {
+		return nil
+	}
+	return func(state tls.ConnectionState) error {
+		for _, certificate := range state.PeerCertificates {
+			digest := sha256.Sum256(certificate.RawSubjectPublicKeyInfo)
+			for _, pin := range pins {
+				if bytes.Equal(digest[:], pin) {
+					return nil
+				}
+			}
+		}
+		return ErrSPKIPinMismatch
+	}
+}
@@ -0,0 +1,43 @@
+//go:build linux
+
+package common
+
+import (
+	"golang.org/x/sys/unix"
+	"syscall"
+)
+
+const (
+	BindToDeviceSupported = true
+	ReusePortSupported    = true
+)
+
+// BindToDevice returns a net.Dialer Control function which binds sockets to
+// the network interface named device, using SO_BINDTODEVICE. It returns nil if
+// device is empty.
+func BindToDevice(device string) func(network, address string, c syscall.RawConn) error {
+	if device == "" {
+		return nil
+	}
+	return func(network, address string, c syscall.RawConn) error {
+		var sockErr error
+		if err := c.Control(func(fd uintptr) {
+			sockErr = syscall.SetsockoptString(int(fd), syscall.SOL_SOCKET, syscall.SO_BINDTODEVICE, device)
+		}); err != nil {
+			return err
+		}
+		return sockErr
+	}
+}
+
+// ReusePort is a net.ListenConfig Control function which sets SO_REUSEPORT on
+// sockets, so that several sockets can listen on the same address and port.
+func ReusePort(network, address string, c syscall.RawConn) error {
+	var sockErr error
+	if err := c.Control(func(fd uintptr) {
+		sockErr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEPORT, 1)
+	}); err != nil {
+		return err
+	}
+	return sockErr
+}
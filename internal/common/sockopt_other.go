@@ -0,0 +1,36 @@
+//go:build !linux
+
+package common
+
+import (
+	"errors"
+	"syscall"
+)
+
+const (
+	BindToDeviceSupported = false
+	ReusePortSupported    = false
+)
+
+var (
+	errBindToDeviceUnsupported = errors.New("common: Binding to a network interface is not supported on this platform")
+	errReusePortUnsupported    = errors.New("common: SO_REUSEPORT is not supported on this platform")
+)
+
+// BindToDevice returns a net.Dialer Control function which fails every dial,
+// since binding sockets to a network interface is only supported on Linux. It
+// returns nil if device is empty.
+func BindToDevice(device string) func(network, address string, c syscall.RawConn) error {
+	if device == "" {
+		return nil
+	}
+	return func(network, address string, c syscall.RawConn) error {
+		return errBindToDeviceUnsupported
+	}
+}
+
+// ReusePort is a net.ListenConfig Control function which fails every listen,
+// since SO_REUSEPORT is only supported on Linux.
+func ReusePort(network, address string, c syscall.RawConn) error {
+	return errReusePortUnsupported
+}
@@ -1,12 +1,17 @@
 package config
 
-import "errors"
+import (
+	"errors"
+	"net"
+	"strconv"
+)
 
 var (
 	ErrBadConfig                    = errors.New("config: Bad config")
 	ErrUnexpectedBadConfig          = UnexpectedError("while loading the configuration")
 	ErrMissingListenersConfig       = MissingRequiredConfigError("listeners")
 	ErrMissingDefaultResolverConfig = MissingRequiredConfigError("default resolver")
+	ErrNilReply                     = errors.New("config: Resolver replied nothing")
 )
 
 type UnexpectedError string
@@ -26,3 +31,25 @@ type UnsupportedConfigTypeError string
 func (e UnsupportedConfigTypeError) Error() string {
 	return "config: Type of config for " + string(e) + " not supported"
 }
+
+type UnknownResolverTypeError string
+
+func (e UnknownResolverTypeError) Error() string {
+	return "config: Unknown resolver type " + string(e)
+}
+
+type InvalidResolverConfigError string
+
+func (e InvalidResolverConfigError) Error() string {
+	return "config: Invalid config for resolver named " + string(e)
+}
+
+type ListenerConflictError struct {
+	first  int
+	second int
+	addr   net.Addr
+}
+
+func (e ListenerConflictError) Error() string {
+	return "config: Listeners at index " + strconv.Itoa(e.first) + " and " + strconv.Itoa(e.second) + " conflict on " + e.addr.Network() + " " + e.addr.String()
+}
@@ -2,37 +2,47 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	common2 "github.com/zhouchenh/secDNS/internal/common"
 	named "github.com/zhouchenh/secDNS/internal/config/named/resolver"
 	"github.com/zhouchenh/secDNS/internal/core"
+	"github.com/zhouchenh/secDNS/pkg/listeners/server"
 	"io"
 	"io/ioutil"
+	"net"
 )
 
-func LoadConfig(r io.Reader) (core.Instance, error) {
+func LoadConfig(r io.Reader) (core.Instance, *Config, error) {
 	rawData, err := ioutil.ReadAll(r)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	var data interface{}
 	err = json.Unmarshal(rawData, &data)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	configErrors = nil
 	rawConfig, s, f := Descriptor().Describe(data)
+	if len(configErrors) > 0 {
+		return nil, nil, errors.Join(configErrors...)
+	}
 	ok := s > 0 && f < 1
 	if !ok {
-		return nil, ErrBadConfig
+		return nil, nil, ErrBadConfig
 	}
 	config, ok := rawConfig.(*Config)
 	if !ok || config == nil || config.Resolvers == nil {
-		return nil, ErrBadConfig
+		return nil, nil, ErrBadConfig
 	}
 	if len(config.Listeners) < 1 {
-		return nil, ErrMissingListenersConfig
+		return nil, nil, ErrMissingListenersConfig
 	}
 	if config.DefaultResolver == nil {
-		return nil, ErrMissingDefaultResolverConfig
+		return nil, nil, ErrMissingDefaultResolverConfig
+	}
+	if err := checkListenerConflicts(config.Listeners); err != nil {
+		return nil, nil, err
 	}
 	instance := core.NewInstance()
 	instance.AddListener(config.Listeners...)
@@ -41,18 +51,104 @@ func LoadConfig(r io.Reader) (core.Instance, error) {
 		instance.AcceptProvider(p, common2.ErrOutputErrorHandler)
 	}
 	instance.SetDefaultResolver(config.DefaultResolver)
+	instance.SetChaosResolver(config.ChaosResolver)
 	instance.SetResolutionDepth(config.ResolutionDepth)
+	instance.SetSpecialUseNames(config.SpecialUseNames)
+	instance.SetResolveFailurePolicy(config.OnResolveFailure)
+	if config.VersionRecord {
+		instance.SetVersionName(config.VersionName)
+	}
+	instance.SetStripUnrequestedDNSSEC(config.StripDNSSEC)
 	instanceResolver, ok := instance.GetResolver()
 	if !ok {
-		return nil, ErrUnexpectedBadConfig
+		return nil, nil, ErrUnexpectedBadConfig
 	}
 	err = config.Resolvers.NameResolver("", instanceResolver)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	err = named.InitKnownNamedResolvers()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	return instance, config, nil
+}
+
+func checkListenerConflicts(listeners []server.Server) error {
+	type endpoint struct {
+		index int
+		server.Endpoint
+	}
+	var endpoints []endpoint
+	for index, listener := range listeners {
+		bindable, ok := listener.(server.Bindable)
+		if !ok {
+			continue
+		}
+		for _, e := range bindable.Endpoints() {
+			for _, other := range endpoints {
+				if endpointsConflict(other.Endpoint, e) {
+					return ListenerConflictError{first: other.index, second: index, addr: e.Addr}
+				}
+			}
+			endpoints = append(endpoints, endpoint{index: index, Endpoint: e})
+		}
+	}
+	return nil
+}
+
+// endpointsConflict reports whether binding both endpoints would fail. Sockets
+// on port 0 get distinct ephemeral ports, sockets which both set SO_REUSEPORT
+// may share an address, and sockets bound to different interfaces do not
+// compete with each other.
+func endpointsConflict(a, b server.Endpoint) bool {
+	if a.Addr.Network() != b.Addr.Network() {
+		return false
+	}
+	if (a.ReusePort && b.ReusePort) || a.Interface != b.Interface {
+		return false
+	}
+	ipA, portA, ok := splitAddr(a.Addr)
+	if !ok {
+		return a.Addr.String() == b.Addr.String()
 	}
-	return instance, nil
+	ipB, portB, ok := splitAddr(b.Addr)
+	if !ok {
+		return false
+	}
+	if portA == 0 || portB == 0 || portA != portB {
+		return false
+	}
+	if ipA.Equal(ipB) {
+		return true
+	}
+	return covers(ipA, a.V6Only, ipB) || covers(ipB, b.V6Only, ipA)
+}
+
+func splitAddr(addr net.Addr) (ip net.IP, port int, ok bool) {
+	switch addr := addr.(type) {
+	case *net.UDPAddr:
+		return addr.IP, addr.Port, true
+	case *net.TCPAddr:
+		return addr.IP, addr.Port, true
+	default:
+		return nil, 0, false
+	}
+}
+
+// covers reports whether a socket bound to the unspecified address ip also
+// takes other. An unspecified address only covers addresses of its own family,
+// except that an IPv6 one without IPV6_V6ONLY accepts IPv4 as well.
+func covers(ip net.IP, v6Only bool, other net.IP) bool {
+	if !ip.IsUnspecified() {
+		return false
+	}
+	if isIPv4(ip) == isIPv4(other) {
+		return true
+	}
+	return !isIPv4(ip) && !v6Only
+}
+
+func isIPv4(ip net.IP) bool {
+	return ip.To4() != nil
 }
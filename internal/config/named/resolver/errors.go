@@ -1,5 +1,7 @@
 package resolver
 
+import "strings"
+
 var ErrNilNameRegistry = NilPointerError("name registry")
 
 type NilPointerError string
@@ -14,6 +16,15 @@ func (e NotFoundError) Error() string {
 	return "config/named/resolver: Resolver named " + string(e) + " not found"
 }
 
+type NotFoundErrors []string
+
+func (e NotFoundErrors) Error() string {
+	if len(e) == 1 {
+		return NotFoundError(e[0]).Error()
+	}
+	return "config/named/resolver: Resolvers named " + strings.Join(e, ", ") + " not found"
+}
+
 type AlreadyExistedError string
 
 func (e AlreadyExistedError) Error() string {
@@ -1,6 +1,9 @@
 package resolver
 
-import "github.com/zhouchenh/secDNS/pkg/upstream/resolver"
+import (
+	"github.com/zhouchenh/secDNS/pkg/upstream/resolver"
+	"sort"
+)
 
 type NameRegistry struct {
 	registry map[string]resolver.Resolver
@@ -19,3 +22,17 @@ func (nr *NameRegistry) NameResolver(name string, r resolver.Resolver) error {
 	nr.registry[name] = r
 	return nil
 }
+
+func (nr *NameRegistry) Range(f func(name string, r resolver.Resolver)) {
+	if nr == nil || f == nil {
+		return
+	}
+	names := make([]string, 0, len(nr.registry))
+	for name := range nr.registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		f(name, nr.registry[name])
+	}
+}
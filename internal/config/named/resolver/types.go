@@ -119,15 +119,21 @@ func reportNamedResolver(namedResolver *NamedResolver) {
 }
 
 func InitKnownNamedResolvers() error {
+	var notFound NotFoundErrors
+	reported := make(map[string]bool)
 	for _, namedResolver := range knownNamedResolvers {
 		if namedResolver.resolver != nil {
 			continue
 		}
 		namedResolver.Init()
-		if namedResolver.resolver == nil {
-			return NotFoundError(namedResolver.Name)
+		if namedResolver.resolver == nil && !reported[namedResolver.Name] {
+			reported[namedResolver.Name] = true
+			notFound = append(notFound, namedResolver.Name)
 		}
 	}
 	knownNamedResolvers = nil
+	if len(notFound) > 0 {
+		return notFound
+	}
 	return nil
 }
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"github.com/miekg/dns"
+	"github.com/zhouchenh/secDNS/internal/common"
+	"github.com/zhouchenh/secDNS/pkg/upstream/resolver"
+	"time"
+)
+
+type SelfTestResult struct {
+	Name     string
+	Resolver resolver.Resolver
+	Duration time.Duration
+	Rcode    int
+	Err      error
+}
+
+// SelfTest queries the A record of the canary domain name through the
+// default resolver and each named resolver of the configuration, reporting
+// every result. It returns false if any query fails.
+func (c *Config) SelfTest(canary string, report func(result SelfTestResult)) (ok bool) {
+	if c == nil {
+		return false
+	}
+	ok = true
+	test := func(name string, r resolver.Resolver) {
+		query := new(dns.Msg)
+		query.SetQuestion(common.EnsureFQDN(canary), dns.TypeA)
+		start := time.Now()
+		reply, err := r.Resolve(query, c.ResolutionDepth)
+		result := SelfTestResult{
+			Name:     name,
+			Resolver: r,
+			Duration: time.Since(start),
+			Err:      err,
+		}
+		if err == nil && reply == nil {
+			result.Err = ErrNilReply
+		}
+		if reply != nil {
+			result.Rcode = reply.Rcode
+		}
+		if result.Err != nil || result.Rcode == dns.RcodeServerFailure || result.Rcode == dns.RcodeRefused {
+			ok = false
+		}
+		if report != nil {
+			report(result)
+		}
+	}
+	test("", c.DefaultResolver)
+	c.Resolvers.Range(func(name string, r resolver.Resolver) {
+		if name == "" {
+			return
+		}
+		test(name, r)
+	})
+	return
+}
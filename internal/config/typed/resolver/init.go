@@ -6,6 +6,12 @@ import (
 	"github.com/zhouchenh/secDNS/pkg/upstream/resolver"
 )
 
+var loadErrorHandler func(err error)
+
+func SetLoadErrorHandler(handler func(err error)) {
+	loadErrorHandler = handler
+}
+
 func init() {
 	resolver.RegisterAssignmentFunctionByType(descriptor.TypeOfNew(new(typed.Value)), func(i interface{}) (object interface{}, ok bool) {
 		typedValue, ok := i.(typed.Value)
@@ -21,6 +27,17 @@ func init() {
 		}
 		object, s, f := describable.Describe(typedValue.Value)
 		ok = s > 0 && f < 1
+		if !ok {
+			return
+		}
+		if loader, isLoader := object.(resolver.Loader); isLoader {
+			if err := loader.Load(); err != nil {
+				if loadErrorHandler != nil {
+					loadErrorHandler(err)
+				}
+				return nil, false
+			}
+		}
 		return
 	})
 }
@@ -1,8 +1,11 @@
 package config
 
 import (
+	"github.com/miekg/dns"
 	"github.com/zhouchenh/go-descriptor"
 	named "github.com/zhouchenh/secDNS/internal/config/named/resolver"
+	typed "github.com/zhouchenh/secDNS/internal/config/typed/resolver"
+	"github.com/zhouchenh/secDNS/internal/core"
 	"github.com/zhouchenh/secDNS/pkg/listeners/server"
 	"github.com/zhouchenh/secDNS/pkg/rules/provider"
 	"github.com/zhouchenh/secDNS/pkg/upstream/resolver"
@@ -10,15 +13,27 @@ import (
 )
 
 type Config struct {
-	Listeners       []server.Server
-	Resolvers       *named.NameRegistry
-	Rules           []provider.Provider
-	DefaultResolver resolver.Resolver
-	ResolutionDepth int
+	Listeners        []server.Server
+	Resolvers        *named.NameRegistry
+	Rules            []provider.Provider
+	DefaultResolver  resolver.Resolver
+	ChaosResolver    resolver.Resolver
+	ResolutionDepth  int
+	SpecialUseNames  bool
+	OnResolveFailure string
+	VersionRecord    bool
+	VersionName      string
+	StripDNSSEC      bool
 }
 
 var typeOfConfig = descriptor.TypeOfNew(new(*Config))
 
+var configErrors []error
+
+func reportConfigError(err error) {
+	configErrors = append(configErrors, err)
+}
+
 func Type() descriptor.Type {
 	return typeOfConfig
 }
@@ -68,6 +83,7 @@ func Descriptor() descriptor.Describable {
 						named.SetNameRegistryAssignmentFunction(func(interface{}) (interface{}, bool) {
 							return nameRegistry, true
 						})
+						typed.SetLoadErrorHandler(reportConfigError)
 						return nameRegistry, true
 					}),
 				},
@@ -86,24 +102,35 @@ func Descriptor() descriptor.Describable {
 								for resolverTypeName, resolversByType := range m {
 									describable, ok := resolver.GetResolverDescriptorByTypeName(resolverTypeName)
 									if !ok {
+										reportConfigError(UnknownResolverTypeError(resolverTypeName))
 										continue
 									}
 									resolvers, ok := resolversByType.(map[string]interface{})
 									if !ok {
+										reportConfigError(UnsupportedConfigTypeError(resolverTypeName))
 										continue
 									}
 									for name, config := range resolvers {
 										rawResolver, s, f := describable.Describe(config)
 										ok := s > 0 && f < 1
 										if !ok {
+											reportConfigError(InvalidResolverConfigError(name))
 											continue
 										}
 										r, ok := rawResolver.(resolver.Resolver)
 										if !ok {
+											reportConfigError(InvalidResolverConfigError(name))
 											continue
 										}
+										if loader, isLoader := r.(resolver.Loader); isLoader {
+											if err := loader.Load(); err != nil {
+												reportConfigError(err)
+												continue
+											}
+										}
 										err := nameRegistry.NameResolver(name, r)
 										if err != nil {
+											reportConfigError(err)
 											continue
 										}
 									}
@@ -162,10 +189,31 @@ func Descriptor() descriptor.Describable {
 					}),
 				},
 			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"ChaosResolver"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"chaosResolver"},
+						AssignableKind: descriptor.AssignmentFunction(func(i interface{}) (object interface{}, ok bool) {
+							object, s, f := resolver.Descriptor().Describe(i)
+							ok = s > 0 && f < 1
+							return
+						}),
+					},
+					descriptor.ObjectAtPath{
+						AssignableKind: descriptor.AssignmentFunction(func(interface{}) (object interface{}, ok bool) {
+							object, s, f := resolver.Descriptor().Describe(map[string]interface{}{"type": "chaos"})
+							ok = s > 0 && f < 1
+							return
+						}),
+					},
+				},
+			},
 			descriptor.ObjectFiller{
 				ValueSource: descriptor.ObjectAtPath{
 					AssignableKind: descriptor.AssignmentFunction(func(interface{}) (interface{}, bool) {
 						named.SetNameRegistryAssignmentFunction(nil)
+						typed.SetLoadErrorHandler(nil)
 						return nil, true
 					}),
 				},
@@ -206,6 +254,139 @@ func Descriptor() descriptor.Describable {
 					descriptor.DefaultValue{Value: 64},
 				},
 			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"SpecialUseNames"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"specialUseNames"},
+						AssignableKind: descriptor.AssignableKinds{
+							descriptor.KindBool,
+							descriptor.ConvertibleKind{
+								Kind: descriptor.KindString,
+								ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+									str, ok := original.(string)
+									if !ok {
+										return
+									}
+									switch str {
+									case "true":
+										return true, true
+									case "false":
+										return false, true
+									default:
+										return
+									}
+								},
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: true},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"OnResolveFailure"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"onResolveFailure"},
+						AssignableKind: descriptor.ConvertibleKind{
+							Kind: descriptor.KindString,
+							ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+								str, ok := original.(string)
+								if !ok {
+									return
+								}
+								switch str {
+								case core.ResolveFailureServFail, core.ResolveFailureRefuse, core.ResolveFailureNXDomain, core.ResolveFailureNoAnswer:
+									return str, true
+								default:
+									return nil, false
+								}
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: core.ResolveFailureServFail},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"VersionRecord"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"versionRecord"},
+						AssignableKind: descriptor.AssignableKinds{
+							descriptor.KindBool,
+							descriptor.ConvertibleKind{
+								Kind: descriptor.KindString,
+								ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+									str, ok := original.(string)
+									if !ok {
+										return
+									}
+									switch str {
+									case "true":
+										return true, true
+									case "false":
+										return false, true
+									default:
+										return
+									}
+								},
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: false},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"VersionName"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"versionName"},
+						AssignableKind: descriptor.ConvertibleKind{
+							Kind: descriptor.KindString,
+							ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+								str, ok := original.(string)
+								if !ok {
+									return
+								}
+								if _, ok := dns.IsDomainName(str); !ok || str == "" || str == "." {
+									return nil, false
+								}
+								return dns.Fqdn(str), true
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: "version.secdns.local."},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"StripDNSSEC"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"stripUnrequestedDNSSEC"},
+						AssignableKind: descriptor.AssignableKinds{
+							descriptor.KindBool,
+							descriptor.ConvertibleKind{
+								Kind: descriptor.KindString,
+								ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+									str, ok := original.(string)
+									if !ok {
+										return
+									}
+									switch str {
+									case "true":
+										return true, true
+									case "false":
+										return false, true
+									default:
+										return
+									}
+								},
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: true},
+				},
+			},
 		},
 	}
 }
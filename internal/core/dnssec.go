@@ -0,0 +1,40 @@
+package core
+
+import (
+	"github.com/miekg/dns"
+	"github.com/zhouchenh/secDNS/internal/common"
+)
+
+// stripUnrequestedDNSSEC removes DNSSEC resource records from replies to
+// queries without the DO bit, which did not ask for them (RFC 3225 section 3).
+// Records of the queried type are kept in the answer section, so that explicit
+// queries for them still work. The AD bit is left unchanged.
+func stripUnrequestedDNSSEC(query *dns.Msg, reply *dns.Msg) {
+	if len(query.Question) != 1 {
+		return
+	}
+	if opt := query.IsEdns0(); opt != nil && opt.Do() {
+		return
+	}
+	qtype := query.Question[0].Qtype
+	reply.Answer = common.FilterResourceRecords(reply.Answer, func(rr dns.RR) bool {
+		return rr.Header().Rrtype == qtype || !isDNSSECRecord(rr, false)
+	})
+	reply.Ns = common.FilterResourceRecords(reply.Ns, func(rr dns.RR) bool {
+		return !isDNSSECRecord(rr, false)
+	})
+	reply.Extra = common.FilterResourceRecords(reply.Extra, func(rr dns.RR) bool {
+		return !isDNSSECRecord(rr, true)
+	})
+}
+
+func isDNSSECRecord(rr dns.RR, includeKeys bool) bool {
+	switch rr.Header().Rrtype {
+	case dns.TypeRRSIG, dns.TypeNSEC, dns.TypeNSEC3:
+		return true
+	case dns.TypeDNSKEY:
+		return includeKeys
+	default:
+		return false
+	}
+}
@@ -3,6 +3,7 @@ package core
 import (
 	"github.com/miekg/dns"
 	"github.com/zhouchenh/go-descriptor"
+	"github.com/zhouchenh/secDNS/internal/common"
 	"github.com/zhouchenh/secDNS/pkg/listeners/server"
 	"github.com/zhouchenh/secDNS/pkg/rules/provider"
 	"github.com/zhouchenh/secDNS/pkg/upstream/resolver"
@@ -15,18 +16,37 @@ type Instance interface {
 	AddListener(listeners ...server.Server)
 	AcceptProvider(rulesProvider provider.Provider, errorHandler func(err error))
 	SetDefaultResolver(upstreamResolver resolver.Resolver)
+	SetChaosResolver(upstreamResolver resolver.Resolver)
 	SetResolutionDepth(depth int)
+	SetSpecialUseNames(enabled bool)
+	SetResolveFailurePolicy(policy string)
+	SetVersionName(name string)
+	SetStripUnrequestedDNSSEC(enabled bool)
 	GetResolver() (upstreamResolver resolver.Resolver, ok bool)
-	Listen(clientErrorMsgHandler func(query *dns.Msg) *dns.Msg, serverErrorMsgHandler func(query *dns.Msg) *dns.Msg, errorHandler func(err error))
+	Listen(clientErrorMsgHandler func(query *dns.Msg) *dns.Msg, serverErrorMsgHandler func(query *dns.Msg, err error) *dns.Msg, errorHandler func(err error))
 }
 
 type instance struct {
 	listeners       []server.Server
 	nameResolverMap map[string]resolver.Resolver // fully qualified names are required
+	providerRules   []map[string]resolver.Resolver
+	rulesMutex      sync.RWMutex
 	defaultResolver resolver.Resolver
+	chaosResolver   resolver.Resolver
 	resolutionDepth int
+	specialUseNames bool
+	failurePolicy   string
+	versionName     string
+	stripDNSSEC     bool
 }
 
+const (
+	ResolveFailureServFail = "servfail"
+	ResolveFailureRefuse   = "refuse"
+	ResolveFailureNXDomain = "nxdomain"
+	ResolveFailureNoAnswer = "noAnswer"
+)
+
 func NewInstance() Instance {
 	i := new(instance)
 	i.initInstance()
@@ -45,18 +65,55 @@ func (i *instance) AcceptProvider(rulesProvider provider.Provider, errorHandler
 	if rulesProvider == nil {
 		return
 	}
+	i.rulesMutex.Lock()
+	index := len(i.providerRules)
+	i.providerRules = append(i.providerRules, nil)
+	i.rulesMutex.Unlock()
+	if refreshable, ok := rulesProvider.(provider.Refreshable); ok {
+		refreshable.SetRefreshHandler(func() {
+			i.loadRules(index, rulesProvider, errorHandler)
+		})
+	}
+	i.loadRules(index, rulesProvider, errorHandler)
+}
+
+// loadRules collects the rules of the provider at index, and replaces the
+// name map with one rebuilt from the rules of all providers, so that queries
+// being resolved keep using a consistent map. Names provided by an earlier
+// provider take precedence.
+func (i *instance) loadRules(index int, rulesProvider provider.Provider, errorHandler func(err error)) {
+	rules := make(map[string]resolver.Resolver)
 	for rulesProvider.Provide(func(name string, r resolver.Resolver) {
 		if r == nil {
 			return
 		}
-		if _, hasKey := i.nameResolverMap[name]; hasKey {
+		name = common.ToASCII(name)
+		if _, hasKey := rules[name]; hasKey {
 			return
 		}
-		i.nameResolverMap[name] = r
+		rules[name] = r
 	}, func(err error) {
 		go handleIfError(err, errorHandler)
 	}) {
 	}
+	i.rulesMutex.Lock()
+	defer i.rulesMutex.Unlock()
+	i.providerRules[index] = rules
+	nameResolverMap := make(map[string]resolver.Resolver)
+	for _, providerRules := range i.providerRules {
+		for name, r := range providerRules {
+			if _, hasKey := nameResolverMap[name]; !hasKey {
+				nameResolverMap[name] = r
+			}
+		}
+	}
+	i.nameResolverMap = nameResolverMap
+}
+
+func (i *instance) rules() map[string]resolver.Resolver {
+	i.rulesMutex.RLock()
+	defer i.rulesMutex.RUnlock()
+	return i.nameResolverMap
 }
 
 func (i *instance) SetDefaultResolver(upstreamResolver resolver.Resolver) {
@@ -66,10 +123,30 @@ func (i *instance) SetDefaultResolver(upstreamResolver resolver.Resolver) {
 	i.defaultResolver = upstreamResolver
 }
 
+func (i *instance) SetChaosResolver(upstreamResolver resolver.Resolver) {
+	i.chaosResolver = upstreamResolver
+}
+
 func (i *instance) SetResolutionDepth(depth int) {
 	i.resolutionDepth = depth
 }
 
+func (i *instance) SetSpecialUseNames(enabled bool) {
+	i.specialUseNames = enabled
+}
+
+func (i *instance) SetResolveFailurePolicy(policy string) {
+	i.failurePolicy = policy
+}
+
+func (i *instance) SetVersionName(name string) {
+	i.versionName = strings.ToLower(name)
+}
+
+func (i *instance) SetStripUnrequestedDNSSEC(enabled bool) {
+	i.stripDNSSEC = enabled
+}
+
 func (i *instance) GetResolver() (upstreamResolver resolver.Resolver, ok bool) {
 	if i.defaultResolver == nil {
 		return nil, false
@@ -77,7 +154,7 @@ func (i *instance) GetResolver() (upstreamResolver resolver.Resolver, ok bool) {
 	return i, true
 }
 
-func (i *instance) Listen(clientErrorMsgHandler func(query *dns.Msg) *dns.Msg, serverErrorMsgHandler func(query *dns.Msg) *dns.Msg, errorHandler func(err error)) {
+func (i *instance) Listen(clientErrorMsgHandler func(query *dns.Msg) *dns.Msg, serverErrorMsgHandler func(query *dns.Msg, err error) *dns.Msg, errorHandler func(err error)) {
 	if clientErrorMsgHandler == nil || serverErrorMsgHandler == nil {
 		handleIfError(ErrNilErrorMsgHandler, errorHandler)
 		return
@@ -93,13 +170,26 @@ func (i *instance) Listen(clientErrorMsgHandler func(query *dns.Msg) *dns.Msg, s
 			continue
 		}
 		wait.Add(1)
-		go listen(listener, instanceResolver, i.resolutionDepth, clientErrorMsgHandler, serverErrorMsgHandler, errorHandler, wait)
+		go listen(listener, instanceResolver, i.chaosResolver, i.resolutionDepth, i.stripDNSSEC, clientErrorMsgHandler, serverErrorMsgHandler, errorHandler, wait)
 	}
 	wait.Wait()
 }
 
-func listen(s server.Server, r resolver.Resolver, resolutionDepth int, clientErrorMsgHandler func(query *dns.Msg) *dns.Msg, serverErrorMsgHandler func(query *dns.Msg) *dns.Msg, errorHandler func(err error), wait *sync.WaitGroup) {
-	s.Serve(func(query *dns.Msg) (reply *dns.Msg) {
+func listen(s server.Server, r resolver.Resolver, chaosResolver resolver.Resolver, resolutionDepth int, stripDNSSEC bool, clientErrorMsgHandler func(query *dns.Msg) *dns.Msg, serverErrorMsgHandler func(query *dns.Msg, err error) *dns.Msg, errorHandler func(err error), wait *sync.WaitGroup) {
+	handle := func(query *dns.Msg) (reply *dns.Msg) {
+		if isChaosQuery(query) {
+			if chaosResolver == nil {
+				reply = new(dns.Msg)
+				reply.SetRcode(query, dns.RcodeRefused)
+				return
+			}
+			reply, err := chaosResolver.Resolve(query, resolutionDepth)
+			if err != nil {
+				go handleIfError(err, errorHandler)
+				return serverErrorMsgHandler(query, err)
+			}
+			return reply
+		}
 		if err := resolver.QueryCheck(query); err != nil {
 			go handleIfError(err, errorHandler)
 			return clientErrorMsgHandler(query)
@@ -107,9 +197,19 @@ func listen(s server.Server, r resolver.Resolver, resolutionDepth int, clientErr
 		reply, err := r.Resolve(query, resolutionDepth)
 		if err != nil {
 			go handleIfError(err, errorHandler)
-			return serverErrorMsgHandler(query)
+			return serverErrorMsgHandler(query, err)
 		}
 		return
+	}
+	s.Serve(func(query *dns.Msg) *dns.Msg {
+		reply := handle(query)
+		if query != nil && reply != nil {
+			if stripDNSSEC {
+				stripUnrequestedDNSSEC(query, reply)
+			}
+			common.EnsureEDNS0(query, reply)
+		}
+		return reply
 	}, errorHandler)
 	wait.Done()
 }
@@ -126,12 +226,28 @@ func (i *instance) Resolve(query *dns.Msg, depth int) (*dns.Msg, error) {
 	if depth < 0 {
 		return nil, resolver.ErrLoopDetected
 	}
+	if query == nil {
+		return nil, resolver.ErrNilQuery
+	}
+	switch {
+	case len(query.Question) < 1:
+		return nil, resolver.ErrNoQuestion
+	case len(query.Question) > 1:
+		return nil, resolver.ErrTooManyQuestions
+	}
+	if name := common.ToASCII(query.Question[0].Name); name != query.Question[0].Name {
+		return resolveNormalized(i, query, name, depth)
+	}
+	if msg, ok := i.versionReply(query); ok {
+		return msg, nil
+	}
 	name := query.Question[0].Name
 	labels := strings.Split(name, ".")
 	if len(labels) < 2 {
 		return nil, ErrInvalidDomainName
 	}
-	if r, ok := i.nameResolverMap["\""+name+"\""]; ok {
+	nameResolverMap := i.rules()
+	if r, ok := nameResolverMap["\""+name+"\""]; ok {
 		msg, err := r.Resolve(query, depth-1)
 		if err == nil && msg != nil {
 			return msg, nil
@@ -139,7 +255,7 @@ func (i *instance) Resolve(query *dns.Msg, depth int) (*dns.Msg, error) {
 	}
 	for level := 0; level < len(labels)-1; level++ {
 		domainName := strings.Join(labels[level:], ".")
-		if r, ok := i.nameResolverMap[domainName]; ok {
+		if r, ok := nameResolverMap[domainName]; ok {
 			msg, err := r.Resolve(query, depth-1)
 			if err != nil {
 				continue
@@ -147,13 +263,59 @@ func (i *instance) Resolve(query *dns.Msg, depth int) (*dns.Msg, error) {
 			return msg, nil
 		}
 	}
+	if i.specialUseNames {
+		if msg, ok := specialUseReply(query); ok {
+			return msg, nil
+		}
+	}
 	msg, err := i.defaultResolver.Resolve(query, depth-1)
+	if err != nil {
+		if msg, ok := i.resolveFailureReply(query); ok {
+			return msg, nil
+		}
+		return nil, err
+	}
+	return msg, nil
+}
+
+// resolveFailureReply builds the reply configured for queries which the
+// default resolver fails to resolve. It returns false if the failure should be
+// reported as an error instead.
+func (i *instance) resolveFailureReply(query *dns.Msg) (*dns.Msg, bool) {
+	msg := new(dns.Msg)
+	switch i.failurePolicy {
+	case ResolveFailureRefuse:
+		msg.SetRcode(query, dns.RcodeRefused)
+	case ResolveFailureNXDomain:
+		msg.SetRcode(query, dns.RcodeNameError)
+	case ResolveFailureNoAnswer:
+		msg.SetReply(query)
+	default:
+		return nil, false
+	}
+	msg.RecursionAvailable = true
+	return msg, true
+}
+
+// resolveNormalized resolves a copy of query whose question name is replaced
+// with its A-label form, and restores the original question in the reply.
+func resolveNormalized(r resolver.Resolver, query *dns.Msg, name string, depth int) (*dns.Msg, error) {
+	normalizedQuery := query.Copy()
+	normalizedQuery.Question[0].Name = name
+	msg, err := r.Resolve(normalizedQuery, depth)
 	if err != nil {
 		return nil, err
 	}
+	if msg != nil && len(msg.Question) > 0 {
+		msg.Question = query.Question
+	}
 	return msg, nil
 }
 
+func isChaosQuery(query *dns.Msg) bool {
+	return query != nil && len(query.Question) == 1 && query.Question[0].Qclass == dns.ClassCHAOS
+}
+
 func handleIfError(err error, errorHandler func(err error)) {
 	if err != nil && errorHandler != nil {
 		errorHandler(err)
@@ -0,0 +1,130 @@
+package core
+
+import (
+	"github.com/miekg/dns"
+	"github.com/zhouchenh/go-descriptor"
+	"github.com/zhouchenh/secDNS/internal/common"
+	"github.com/zhouchenh/secDNS/pkg/upstream/resolver"
+	"testing"
+)
+
+// staticRules provides a fixed set of rules in a single pass.
+type staticRules map[string]resolver.Resolver
+
+func (s staticRules) Type() descriptor.Type { return nil }
+func (s staticRules) TypeName() string      { return "staticRules" }
+
+func (s staticRules) Provide(receive func(name string, r resolver.Resolver), receiveError func(err error)) bool {
+	for name, r := range s {
+		receive(name, r)
+	}
+	return false
+}
+
+// recorder replies to every query, and records the question names it is asked.
+type recorder struct {
+	names []string
+}
+
+func (r *recorder) Type() descriptor.Type { return nil }
+func (r *recorder) TypeName() string      { return "recorder" }
+
+func (r *recorder) Resolve(query *dns.Msg, depth int) (*dns.Msg, error) {
+	r.names = append(r.names, query.Question[0].Name)
+	msg := new(dns.Msg)
+	msg.SetReply(query)
+	return msg, nil
+}
+
+func TestResolveMatchesInternationalizedRules(t *testing.T) {
+	unicodeRule, punycodeRule, defaultResolver := new(recorder), new(recorder), new(recorder)
+	i := NewInstance()
+	i.AcceptProvider(staticRules{
+		"münchen.de.":            unicodeRule,
+		"xn--bcher-kva.example.": punycodeRule,
+	}, nil)
+	i.SetDefaultResolver(defaultResolver)
+	tests := []struct {
+		query     string
+		want      *recorder
+		wantAsked string
+	}{
+		{"xn--mnchen-3ya.de.", unicodeRule, "xn--mnchen-3ya.de."},
+		{"www.xn--mnchen-3ya.de.", unicodeRule, "www.xn--mnchen-3ya.de."},
+		{"münchen.de.", unicodeRule, "xn--mnchen-3ya.de."},
+		{"bücher.example.", punycodeRule, "xn--bcher-kva.example."},
+		{"b\\195\\188cher.example.", punycodeRule, "xn--bcher-kva.example."},
+		{"xn--bcher-kva.example.", punycodeRule, "xn--bcher-kva.example."},
+		{"example.com.", defaultResolver, "example.com."},
+	}
+	resolver, _ := i.GetResolver()
+	for _, test := range tests {
+		for _, r := range []*recorder{unicodeRule, punycodeRule, defaultResolver} {
+			r.names = nil
+		}
+		query := new(dns.Msg)
+		query.SetQuestion(test.query, dns.TypeA)
+		reply, err := resolver.Resolve(query, 4)
+		if err != nil {
+			t.Errorf("Resolve(%s) error = %v", test.query, err)
+			continue
+		}
+		if len(test.want.names) != 1 || test.want.names[0] != test.wantAsked {
+			t.Errorf("Resolve(%s) asked %v, want %s", test.query, test.want.names, test.wantAsked)
+		}
+		if len(reply.Question) != 1 || reply.Question[0].Name != test.query {
+			t.Errorf("Resolve(%s) replied question %v, want the original question", test.query, reply.Question)
+		}
+	}
+}
+
+// replayServer serves the queries it holds once, recording the replies.
+type replayServer struct {
+	queries []*dns.Msg
+	replies []*dns.Msg
+}
+
+func (s *replayServer) Type() descriptor.Type { return nil }
+func (s *replayServer) TypeName() string      { return "replayServer" }
+
+func (s *replayServer) Serve(handler func(query *dns.Msg) (reply *dns.Msg), errorHandler func(err error)) {
+	for _, query := range s.queries {
+		s.replies = append(s.replies, handler(query))
+	}
+}
+
+func TestQuestionCount(t *testing.T) {
+	question := dns.Question{Name: "example.com.", Qtype: dns.TypeA, Qclass: dns.ClassINET}
+	tests := []struct {
+		name      string
+		questions []dns.Question
+		want      error
+	}{
+		{"no questions", nil, resolver.ErrNoQuestion},
+		{"two questions", []dns.Question{question, question}, resolver.ErrTooManyQuestions},
+	}
+	defaultResolver := new(recorder)
+	i := NewInstance()
+	i.SetDefaultResolver(defaultResolver)
+	instanceResolver, _ := i.GetResolver()
+	s := new(replayServer)
+	for _, test := range tests {
+		query := new(dns.Msg)
+		query.Id = dns.Id()
+		query.Question = test.questions
+		if _, err := instanceResolver.Resolve(query, 4); err != test.want {
+			t.Errorf("%s: Resolve() error = %v, want %v", test.name, err, test.want)
+		}
+		s.queries = append(s.queries, query)
+	}
+	i.AddListener(s)
+	i.Listen(common.ClientErrorMessageHandler, common.ServerErrorMessageHandler, nil)
+	for index, reply := range s.replies {
+		if reply == nil || reply.Rcode != dns.RcodeFormatError {
+			t.Errorf("%s: reply = %v, want FORMERR", tests[index].name, reply)
+		}
+	}
+	if len(defaultResolver.names) > 0 {
+		t.Errorf("default resolver was asked %v", defaultResolver.names)
+	}
+}
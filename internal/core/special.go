@@ -0,0 +1,88 @@
+package core
+
+import (
+	"github.com/miekg/dns"
+	"github.com/zhouchenh/secDNS/internal/common"
+	"net"
+	"strconv"
+	"strings"
+)
+
+const (
+	localhostZone         = "localhost."
+	loopbackReverseZone   = "127.in-addr.arpa."
+	loopbackReverseNameV6 = "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.ip6.arpa."
+	specialUseTTL         = 86400
+)
+
+// negativeSpecialUseZones are special-use domain names that never exist in the
+// global DNS (RFC 6761, RFC 6762 and RFC 7686).
+var negativeSpecialUseZones = []string{"invalid.", "local.", "onion.", "test."}
+
+// privateReverseZones are the reverse mapping zones of the private address
+// space (RFC 6761 section 6.1), which only have meaning within a local network.
+var privateReverseZones = func() (zones []string) {
+	zones = append(zones, "10.in-addr.arpa.")
+	for i := 16; i <= 31; i++ {
+		zones = append(zones, strconv.Itoa(i)+".172.in-addr.arpa.")
+	}
+	return append(zones, "168.192.in-addr.arpa.")
+}()
+
+// specialUseReply answers queries for special-use domain names locally, so
+// that they are not leaked to upstream DNS servers.
+func specialUseReply(query *dns.Msg) (*dns.Msg, bool) {
+	question := query.Question[0]
+	name := strings.ToLower(question.Name)
+	msg := new(dns.Msg)
+	msg.SetReply(query)
+	msg.Authoritative = true
+	msg.RecursionAvailable = true
+	switch {
+	case dns.IsSubDomain(localhostZone, name):
+		header := dns.RR_Header{Name: question.Name, Rrtype: question.Qtype, Class: dns.ClassINET, Ttl: specialUseTTL}
+		switch question.Qtype {
+		case dns.TypeA:
+			msg.Answer = append(msg.Answer, &dns.A{Hdr: header, A: net.IPv4(127, 0, 0, 1).To4()})
+		case dns.TypeAAAA:
+			msg.Answer = append(msg.Answer, &dns.AAAA{Hdr: header, AAAA: net.IPv6loopback})
+		}
+		return withNegativeSOA(msg, localhostZone), true
+	case dns.IsSubDomain(loopbackReverseZone, name) || name == loopbackReverseNameV6:
+		if question.Qtype == dns.TypePTR && (name == loopbackReverseNameV6 || dns.CountLabel(name) == 6) {
+			msg.Answer = append(msg.Answer, &dns.PTR{
+				Hdr: dns.RR_Header{Name: question.Name, Rrtype: dns.TypePTR, Class: dns.ClassINET, Ttl: specialUseTTL},
+				Ptr: localhostZone,
+			})
+		}
+		if name == loopbackReverseNameV6 {
+			return withNegativeSOA(msg, loopbackReverseNameV6), true
+		}
+		return withNegativeSOA(msg, loopbackReverseZone), true
+	}
+	for _, zone := range privateReverseZones {
+		if dns.IsSubDomain(zone, name) {
+			if name != zone {
+				msg.Rcode = dns.RcodeNameError
+			}
+			return withNegativeSOA(msg, zone), true
+		}
+	}
+	for _, zone := range negativeSpecialUseZones {
+		if dns.IsSubDomain(zone, name) {
+			msg.Rcode = dns.RcodeNameError
+			return withNegativeSOA(msg, zone), true
+		}
+	}
+	return nil, false
+}
+
+// withNegativeSOA adds a synthesized SOA resource record of zone to the
+// authority section of msg if it has no answer, so that the reply can be
+// cached negatively (RFC 2308).
+func withNegativeSOA(msg *dns.Msg, zone string) *dns.Msg {
+	if len(msg.Answer) < 1 {
+		msg.Ns = append(msg.Ns, common.SyntheticSOA(zone, specialUseTTL))
+	}
+	return msg
+}
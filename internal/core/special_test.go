@@ -0,0 +1,59 @@
+package core
+
+import (
+	"github.com/miekg/dns"
+	"testing"
+)
+
+func TestSpecialUseReply(t *testing.T) {
+	tests := []struct {
+		name      string
+		qtype     uint16
+		rcode     int
+		answers   int
+		soaOwner  string
+		forwarded bool
+	}{
+		{"localhost.", dns.TypeA, dns.RcodeSuccess, 1, "", false},
+		{"www.localhost.", dns.TypeAAAA, dns.RcodeSuccess, 1, "", false},
+		{"localhost.", dns.TypeMX, dns.RcodeSuccess, 0, "localhost.", false},
+		{"1.0.0.127.in-addr.arpa.", dns.TypePTR, dns.RcodeSuccess, 1, "", false},
+		{"1.0.0.127.in-addr.arpa.", dns.TypeA, dns.RcodeSuccess, 0, "127.in-addr.arpa.", false},
+		{loopbackReverseNameV6, dns.TypeTXT, dns.RcodeSuccess, 0, loopbackReverseNameV6, false},
+		{"1.2.3.10.in-addr.arpa.", dns.TypePTR, dns.RcodeNameError, 0, "10.in-addr.arpa.", false},
+		{"10.in-addr.arpa.", dns.TypeSOA, dns.RcodeSuccess, 0, "10.in-addr.arpa.", false},
+		{"1.1.16.172.in-addr.arpa.", dns.TypePTR, dns.RcodeNameError, 0, "16.172.in-addr.arpa.", false},
+		{"1.1.31.172.in-addr.arpa.", dns.TypePTR, dns.RcodeNameError, 0, "31.172.in-addr.arpa.", false},
+		{"1.1.168.192.in-addr.arpa.", dns.TypePTR, dns.RcodeNameError, 0, "168.192.in-addr.arpa.", false},
+		{"foo.test.", dns.TypeA, dns.RcodeNameError, 0, "test.", false},
+		{"printer.LOCAL.", dns.TypeA, dns.RcodeNameError, 0, "local.", false},
+		{"1.1.32.172.in-addr.arpa.", dns.TypePTR, 0, 0, "", true},
+		{"1.1.1.1.in-addr.arpa.", dns.TypePTR, 0, 0, "", true},
+		{"example.com.", dns.TypeA, 0, 0, "", true},
+	}
+	for _, test := range tests {
+		query := new(dns.Msg)
+		query.SetQuestion(test.name, test.qtype)
+		reply, ok := specialUseReply(query)
+		if ok == test.forwarded {
+			t.Errorf("specialUseReply(%s) answered = %v, want %v", test.name, ok, !test.forwarded)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if reply.Rcode != test.rcode || len(reply.Answer) != test.answers {
+			t.Errorf("specialUseReply(%s) = %s with %d answers, want %s with %d", test.name,
+				dns.RcodeToString[reply.Rcode], len(reply.Answer), dns.RcodeToString[test.rcode], test.answers)
+		}
+		var soaOwner string
+		if len(reply.Ns) == 1 {
+			if soa, isSOA := reply.Ns[0].(*dns.SOA); isSOA {
+				soaOwner = soa.Hdr.Name
+			}
+		}
+		if soaOwner != test.soaOwner {
+			t.Errorf("specialUseReply(%s) SOA owner = %q, want %q", test.name, soaOwner, test.soaOwner)
+		}
+	}
+}
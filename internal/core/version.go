@@ -0,0 +1,35 @@
+package core
+
+import (
+	"github.com/miekg/dns"
+	"strings"
+)
+
+// versionReply answers IN class queries for the configured version name with
+// the version statement in a TXT record, for monitoring tools which cannot
+// send CHAOS class queries. Other types at the name are replied without any
+// record, and names below it with an NXDOMAIN error.
+func (i *instance) versionReply(query *dns.Msg) (*dns.Msg, bool) {
+	if i.versionName == "" {
+		return nil, false
+	}
+	question := query.Question[0]
+	name := strings.ToLower(question.Name)
+	if question.Qclass != dns.ClassINET || !dns.IsSubDomain(i.versionName, name) {
+		return nil, false
+	}
+	msg := new(dns.Msg)
+	msg.SetReply(query)
+	msg.Authoritative = true
+	msg.RecursionAvailable = true
+	switch {
+	case name != i.versionName:
+		msg.Rcode = dns.RcodeNameError
+	case question.Qtype == dns.TypeTXT || question.Qtype == dns.TypeANY:
+		msg.Answer = append(msg.Answer, &dns.TXT{
+			Hdr: dns.RR_Header{Name: question.Name, Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: 0},
+			Txt: []string{VersionStatement()[0]},
+		})
+	}
+	return msg, true
+}
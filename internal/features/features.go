@@ -15,17 +15,34 @@ import (
 
 	_ "github.com/zhouchenh/secDNS/internal/upstream/resolvers/address"
 	_ "github.com/zhouchenh/secDNS/internal/upstream/resolvers/alias"
+	_ "github.com/zhouchenh/secDNS/internal/upstream/resolvers/any/query"
+	_ "github.com/zhouchenh/secDNS/internal/upstream/resolvers/by/qtype"
+	_ "github.com/zhouchenh/secDNS/internal/upstream/resolvers/chaos"
 	_ "github.com/zhouchenh/secDNS/internal/upstream/resolvers/concurrent/nameserver/list"
+	_ "github.com/zhouchenh/secDNS/internal/upstream/resolvers/delay"
 	_ "github.com/zhouchenh/secDNS/internal/upstream/resolvers/dns64"
 	_ "github.com/zhouchenh/secDNS/internal/upstream/resolvers/doh"
+	_ "github.com/zhouchenh/secDNS/internal/upstream/resolvers/drop/types"
 	_ "github.com/zhouchenh/secDNS/internal/upstream/resolvers/filter/out/a"
 	_ "github.com/zhouchenh/secDNS/internal/upstream/resolvers/filter/out/a/if/aaaa/presents"
 	_ "github.com/zhouchenh/secDNS/internal/upstream/resolvers/filter/out/aaaa"
 	_ "github.com/zhouchenh/secDNS/internal/upstream/resolvers/filter/out/aaaa/if/a/presents"
+	_ "github.com/zhouchenh/secDNS/internal/upstream/resolvers/forward/zone"
+	_ "github.com/zhouchenh/secDNS/internal/upstream/resolvers/max/answers"
+	_ "github.com/zhouchenh/secDNS/internal/upstream/resolvers/mdns/bridge"
 	_ "github.com/zhouchenh/secDNS/internal/upstream/resolvers/nameserver"
 	_ "github.com/zhouchenh/secDNS/internal/upstream/resolvers/no/answer/resolver"
 	_ "github.com/zhouchenh/secDNS/internal/upstream/resolvers/not/exist/resolver"
+	_ "github.com/zhouchenh/secDNS/internal/upstream/resolvers/nxdomain/rate/limit"
+	_ "github.com/zhouchenh/secDNS/internal/upstream/resolvers/rcode/failover"
+	_ "github.com/zhouchenh/secDNS/internal/upstream/resolvers/rebind/protection"
+	_ "github.com/zhouchenh/secDNS/internal/upstream/resolvers/reverse/lookup"
+	_ "github.com/zhouchenh/secDNS/internal/upstream/resolvers/rotate/answers"
 	_ "github.com/zhouchenh/secDNS/internal/upstream/resolvers/sequence"
+	_ "github.com/zhouchenh/secDNS/internal/upstream/resolvers/sort/answers"
+	_ "github.com/zhouchenh/secDNS/internal/upstream/resolvers/static"
+	_ "github.com/zhouchenh/secDNS/internal/upstream/resolvers/transport/fallback"
+	_ "github.com/zhouchenh/secDNS/internal/upstream/resolvers/zone/file"
 
 	_ "github.com/zhouchenh/secDNS/internal/rules/providers/collection"
 	_ "github.com/zhouchenh/secDNS/internal/rules/providers/dnsmasq/conf"
@@ -7,3 +7,9 @@ type NilPointerError string
 func (e NilPointerError) Error() string {
 	return "listeners/servers/dns/server: Nil " + string(e)
 }
+
+type UnsupportedProtocolError string
+
+func (e UnsupportedProtocolError) Error() string {
+	return "listeners/servers/dns/server: Protocol " + string(e) + " not supported"
+}
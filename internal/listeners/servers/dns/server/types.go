@@ -1,18 +1,32 @@
 package server
 
 import (
+	"context"
+	"encoding/hex"
 	"github.com/miekg/dns"
 	"github.com/zhouchenh/go-descriptor"
 	"github.com/zhouchenh/secDNS/internal/common"
 	"github.com/zhouchenh/secDNS/pkg/listeners/server"
 	"net"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 type DNSServer struct {
-	Listen   net.IP
-	Port     uint16
-	Protocol string
+	Listen          net.IP
+	Port            uint16
+	Protocol        string
+	NSID            string
+	TCPIdleTimeout  time.Duration
+	AllowedTypes    []uint16
+	DeniedTypes     []uint16
+	AllowedSuffixes []string
+	UDPReadBuffer   int
+	UDPWriteBuffer  int
+	ReusePort       bool
+	SocketShards    int
 }
 
 var typeOfDNSServer = descriptor.TypeOfNew(new(*DNSServer))
@@ -29,9 +43,243 @@ func (d *DNSServer) Serve(handler func(query *dns.Msg) (reply *dns.Msg), errorHa
 	if handler == nil {
 		handleIfError(ErrNilHandler, errorHandler)
 	}
-	handleIfError(dns.ListenAndServe(net.JoinHostPort(d.Listen.String(), strconv.Itoa(int(d.Port))), d.Protocol, dns.HandlerFunc(func(w dns.ResponseWriter, query *dns.Msg) {
-		handleIfError(w.WriteMsg(handler(query)), errorHandler)
-	})), errorHandler)
+	address := net.JoinHostPort(d.Listen.String(), strconv.Itoa(int(d.Port)))
+	dnsHandler := dns.HandlerFunc(func(w dns.ResponseWriter, query *dns.Msg) {
+		var reply *dns.Msg
+		if d.isAllowed(query) {
+			reply = handler(query)
+		} else {
+			reply = new(dns.Msg)
+			reply.SetRcode(query, dns.RcodeRefused)
+			common.AddExtendedError(query, reply, dns.ExtendedErrorCodeProhibited, "")
+		}
+		d.setNSID(query, reply)
+		if _, isTCP := w.RemoteAddr().(*net.TCPAddr); isTCP {
+			d.setTCPKeepalive(query, reply)
+		}
+		handleIfError(w.WriteMsg(reply), errorHandler)
+	})
+	var idleTimeout func() time.Duration
+	if d.TCPIdleTimeout > 0 {
+		idleTimeout = func() time.Duration {
+			return d.TCPIdleTimeout
+		}
+	}
+	listenConfig := &net.ListenConfig{}
+	if d.ReusePort {
+		listenConfig.Control = common.ReusePort
+	}
+	// Every UDP shard is a separate socket bound to the same address, which
+	// requires SO_REUSEPORT regardless of the reusePort option.
+	shards, udpListenConfig := 1, listenConfig
+	if d.SocketShards > 1 {
+		shards, udpListenConfig = d.SocketShards, &net.ListenConfig{Control: common.ReusePort}
+	}
+	var servers []*dns.Server
+	for _, protocol := range strings.Split(d.Protocol, "+") {
+		var err error
+		switch protocol {
+		case "udp", "udp4", "udp6":
+			for shard := 0; shard < shards; shard++ {
+				s := &dns.Server{Addr: address, Net: protocol, Handler: dnsHandler}
+				if s.PacketConn, err = d.listenUDP(udpListenConfig, protocol, address); err != nil {
+					break
+				}
+				servers = append(servers, s)
+			}
+		case "tcp", "tcp4", "tcp6":
+			s := &dns.Server{Addr: address, Net: protocol, Handler: dnsHandler, IdleTimeout: idleTimeout}
+			if s.Listener, err = listenConfig.Listen(context.Background(), protocol, address); err == nil {
+				servers = append(servers, s)
+			}
+		default:
+			err = UnsupportedProtocolError(protocol)
+		}
+		if err != nil {
+			handleIfError(err, errorHandler)
+			for _, started := range servers {
+				closeListener(started)
+			}
+			return
+		}
+	}
+	once := new(sync.Once)
+	wg := new(sync.WaitGroup)
+	wg.Add(len(servers))
+	for _, s := range servers {
+		go func(s *dns.Server) {
+			err := s.ActivateAndServe()
+			// Close the sockets of the other servers directly, as Shutdown
+			// fails for servers which have not started yet. Only the error of
+			// the server stopping first is reported, since the others fail
+			// because of their closed sockets.
+			once.Do(func() {
+				handleIfError(err, errorHandler)
+				for _, other := range servers {
+					if other != s {
+						closeListener(other)
+					}
+				}
+			})
+			wg.Done()
+		}(s)
+	}
+	wg.Wait()
+}
+
+func (d *DNSServer) listenUDP(listenConfig *net.ListenConfig, network, address string) (net.PacketConn, error) {
+	conn, err := listenConfig.ListenPacket(context.Background(), network, address)
+	if err != nil {
+		return nil, err
+	}
+	udpConn, ok := conn.(*net.UDPConn)
+	if !ok {
+		return conn, nil
+	}
+	if d.UDPReadBuffer > 0 {
+		err = udpConn.SetReadBuffer(d.UDPReadBuffer)
+	}
+	if err == nil && d.UDPWriteBuffer > 0 {
+		err = udpConn.SetWriteBuffer(d.UDPWriteBuffer)
+	}
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (d *DNSServer) Endpoints() (endpoints []server.Endpoint) {
+	for _, protocol := range strings.Split(d.Protocol, "+") {
+		v6Only := strings.HasSuffix(protocol, "6")
+		switch protocol {
+		case "udp", "udp4", "udp6":
+			endpoints = append(endpoints, server.Endpoint{
+				Addr:      &net.UDPAddr{IP: d.Listen, Port: int(d.Port)},
+				ReusePort: d.ReusePort || d.SocketShards > 1,
+				V6Only:    v6Only,
+			})
+		case "tcp", "tcp4", "tcp6":
+			endpoints = append(endpoints, server.Endpoint{
+				Addr:      &net.TCPAddr{IP: d.Listen, Port: int(d.Port)},
+				ReusePort: d.ReusePort,
+				V6Only:    v6Only,
+			})
+		}
+	}
+	return
+}
+
+// isAllowed reports whether query passes the allowedTypes, deniedTypes and
+// allowedSuffixes filters. Queries without exactly one question are left to
+// the handler, which replies to them with a FORMERR.
+func (d *DNSServer) isAllowed(query *dns.Msg) bool {
+	if len(query.Question) != 1 {
+		return true
+	}
+	question := query.Question[0]
+	if d.AllowedTypes != nil && !containsType(d.AllowedTypes, question.Qtype) {
+		return false
+	}
+	if containsType(d.DeniedTypes, question.Qtype) {
+		return false
+	}
+	if d.AllowedSuffixes == nil {
+		return true
+	}
+	name := strings.ToLower(question.Name)
+	for _, suffix := range d.AllowedSuffixes {
+		if dns.IsSubDomain(suffix, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsType(types []uint16, t uint16) bool {
+	for _, each := range types {
+		if each == t {
+			return true
+		}
+	}
+	return false
+}
+
+func parseType(i interface{}) (t uint16, ok bool) {
+	switch value := i.(type) {
+	case string:
+		t, ok = dns.StringToType[strings.ToUpper(value)]
+	case float64:
+		if value >= 0 && value <= 65535 {
+			t, ok = uint16(value), true
+		}
+	}
+	return
+}
+
+func (d *DNSServer) setNSID(query *dns.Msg, reply *dns.Msg) {
+	if d.NSID == "" || query == nil || reply == nil {
+		return
+	}
+	queryOpt := query.IsEdns0()
+	if queryOpt == nil {
+		return
+	}
+	requested := false
+	for _, option := range queryOpt.Option {
+		if option.Option() == dns.EDNS0NSID {
+			requested = true
+			break
+		}
+	}
+	if !requested {
+		return
+	}
+	replyOpt := common.EnsureEDNS0(query, reply)
+	replyOpt.Option = common.FilterEDNS0Options(replyOpt.Option, func(option dns.EDNS0) bool {
+		return option.Option() != dns.EDNS0NSID
+	})
+	replyOpt.Option = append(replyOpt.Option, &dns.EDNS0_NSID{Code: dns.EDNS0NSID, Nsid: hex.EncodeToString([]byte(d.NSID))})
+}
+
+// setTCPKeepalive advertises the idle timeout to clients that send the EDNS0
+// TCP keepalive option, as described in RFC 7828.
+func (d *DNSServer) setTCPKeepalive(query *dns.Msg, reply *dns.Msg) {
+	if d.TCPIdleTimeout <= 0 || query == nil || reply == nil {
+		return
+	}
+	queryOpt := query.IsEdns0()
+	if queryOpt == nil {
+		return
+	}
+	requested := false
+	for _, option := range queryOpt.Option {
+		if option.Option() == dns.EDNS0TCPKEEPALIVE {
+			requested = true
+			break
+		}
+	}
+	if !requested {
+		return
+	}
+	replyOpt := common.EnsureEDNS0(query, reply)
+	timeout := d.TCPIdleTimeout / (100 * time.Millisecond)
+	if timeout > 65535 {
+		timeout = 65535
+	}
+	replyOpt.Option = common.FilterEDNS0Options(replyOpt.Option, func(option dns.EDNS0) bool {
+		return option.Option() != dns.EDNS0TCPKEEPALIVE
+	})
+	replyOpt.Option = append(replyOpt.Option, &dns.EDNS0_TCP_KEEPALIVE{Code: dns.EDNS0TCPKEEPALIVE, Timeout: uint16(timeout)})
+}
+
+func closeListener(s *dns.Server) {
+	if s.PacketConn != nil {
+		_ = s.PacketConn.Close()
+	}
+	if s.Listener != nil {
+		_ = s.Listener.Close()
+	}
 }
 
 func handleIfError(err error, errorHandler func(err error)) {
@@ -41,6 +289,83 @@ func handleIfError(err error, errorHandler func(err error)) {
 }
 
 func init() {
+	socketShardsKind := descriptor.AssignableKinds{
+		descriptor.ConvertibleKind{
+			Kind: descriptor.KindFloat64,
+			ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+				num, ok := original.(float64)
+				if !ok {
+					return
+				}
+				i := int(num)
+				if i >= 1 && i <= 1024 {
+					return i, true
+				}
+				return nil, false
+			},
+		},
+		descriptor.ConvertibleKind{
+			Kind: descriptor.KindString,
+			ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+				str, ok := original.(string)
+				if !ok {
+					return
+				}
+				i, err := strconv.Atoi(str)
+				if err != nil {
+					return nil, false
+				}
+				if i >= 1 && i <= 1024 {
+					return i, true
+				}
+				return nil, false
+			},
+		},
+	}
+	convertibleKindTypes := descriptor.ConvertibleKind{
+		Kind: descriptor.KindSlice,
+		ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+			interfaces, ok := original.([]interface{})
+			if !ok {
+				return
+			}
+			types := make([]uint16, 0, len(interfaces))
+			for _, i := range interfaces {
+				t, ok := parseType(i)
+				if !ok {
+					return nil, false
+				}
+				types = append(types, t)
+			}
+			return types, true
+		},
+	}
+	convertibleKindBufferSize := descriptor.AssignableKinds{
+		descriptor.ConvertibleKind{
+			Kind: descriptor.KindFloat64,
+			ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+				num, ok := original.(float64)
+				if !ok || num < 0 || num > 1<<30 {
+					return nil, false
+				}
+				return int(num), true
+			},
+		},
+		descriptor.ConvertibleKind{
+			Kind: descriptor.KindString,
+			ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+				str, ok := original.(string)
+				if !ok {
+					return
+				}
+				i, err := strconv.Atoi(str)
+				if err != nil || i < 0 || i > 1<<30 {
+					return nil, false
+				}
+				return i, true
+			},
+		},
+	}
 	if err := server.RegisterServer(&descriptor.Descriptor{
 		Type: typeOfDNSServer,
 		Filler: descriptor.Fillers{
@@ -114,6 +439,166 @@ func init() {
 					descriptor.DefaultValue{Value: "udp"},
 				},
 			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"NSID"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath:     descriptor.Path{"nsid"},
+						AssignableKind: descriptor.KindString,
+					},
+					descriptor.DefaultValue{Value: ""},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"TCPIdleTimeout"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"tcpIdleTimeout"},
+						AssignableKind: descriptor.AssignableKinds{
+							descriptor.ConvertibleKind{
+								Kind: descriptor.KindFloat64,
+								ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+									num, ok := original.(float64)
+									if !ok || num < 0 {
+										return nil, false
+									}
+									return time.Duration(num * float64(time.Second)), true
+								},
+							},
+							descriptor.ConvertibleKind{
+								Kind: descriptor.KindString,
+								ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+									str, ok := original.(string)
+									if !ok {
+										return
+									}
+									num, err := strconv.ParseFloat(str, 64)
+									if err != nil || num < 0 {
+										return nil, false
+									}
+									return time.Duration(num * float64(time.Second)), true
+								},
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: time.Duration(0)},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"AllowedTypes"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath:     descriptor.Path{"allowedTypes"},
+						AssignableKind: convertibleKindTypes,
+					},
+					descriptor.DefaultValue{Value: []uint16(nil)},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"DeniedTypes"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath:     descriptor.Path{"deniedTypes"},
+						AssignableKind: convertibleKindTypes,
+					},
+					descriptor.DefaultValue{Value: []uint16(nil)},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"AllowedSuffixes"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"allowedSuffixes"},
+						AssignableKind: descriptor.ConvertibleKind{
+							Kind: descriptor.KindSlice,
+							ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+								interfaces, ok := original.([]interface{})
+								if !ok {
+									return
+								}
+								suffixes := make([]string, 0, len(interfaces))
+								for _, i := range interfaces {
+									str, ok := i.(string)
+									if !ok {
+										return nil, false
+									}
+									if _, ok := dns.IsDomainName(str); !ok {
+										return nil, false
+									}
+									suffixes = append(suffixes, strings.ToLower(dns.Fqdn(str)))
+								}
+								return suffixes, true
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: []string(nil)},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"UDPReadBuffer"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath:     descriptor.Path{"udpReadBuffer"},
+						AssignableKind: convertibleKindBufferSize,
+					},
+					descriptor.DefaultValue{Value: 0},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"UDPWriteBuffer"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath:     descriptor.Path{"udpWriteBuffer"},
+						AssignableKind: convertibleKindBufferSize,
+					},
+					descriptor.DefaultValue{Value: 0},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"ReusePort"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"reusePort"},
+						AssignableKind: descriptor.AssignableKinds{
+							descriptor.KindBool,
+							descriptor.ConvertibleKind{
+								Kind: descriptor.KindString,
+								ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+									str, ok := original.(string)
+									if !ok {
+										return
+									}
+									switch str {
+									case "true":
+										return true, true
+									case "false":
+										return false, true
+									default:
+										return
+									}
+								},
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: false},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"SocketShards"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath:     descriptor.Path{"socketShards"},
+						AssignableKind: socketShardsKind,
+					},
+					// Every shard is served by its own goroutine, so
+					// numWorkers is accepted as another name for socketShards.
+					descriptor.ObjectAtPath{
+						ObjectPath:     descriptor.Path{"numWorkers"},
+						AssignableKind: socketShardsKind,
+					},
+					descriptor.DefaultValue{Value: 1},
+				},
+			},
 		},
 	}); err != nil {
 		common.ErrOutput(err)
@@ -0,0 +1,91 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+type sampledMessage struct {
+	msg        string
+	count      int
+	suppressed int
+}
+
+var (
+	sampleMutex    sync.Mutex
+	sampleLimit    int
+	sampleInterval time.Duration
+	sampleMessages map[string]*sampledMessage
+)
+
+// SetErrorRateLimit limits identical error messages to at most limit per
+// interval. Messages differing only in numbers, such as addresses and ports,
+// are considered identical. Further identical messages within the interval are
+// dropped, and a summary with the number of dropped messages is logged when
+// the interval ends. A limit less than 1 disables the rate limit.
+func SetErrorRateLimit(limit int, interval time.Duration) {
+	sampleMutex.Lock()
+	defer sampleMutex.Unlock()
+	sampleLimit = limit
+	sampleInterval = interval
+	sampleMessages = nil
+}
+
+// Sample reports whether the error message should be logged, according to the
+// limit set by SetErrorRateLimit.
+func Sample(msg string) bool {
+	sampleMutex.Lock()
+	defer sampleMutex.Unlock()
+	if sampleLimit < 1 || sampleInterval <= 0 {
+		return true
+	}
+	if sampleMessages == nil {
+		sampleMessages = make(map[string]*sampledMessage)
+	}
+	key := sampleKey(msg)
+	sample, ok := sampleMessages[key]
+	if !ok {
+		sample = &sampledMessage{msg: msg}
+		sampleMessages[key] = sample
+		messages := sampleMessages
+		time.AfterFunc(sampleInterval, func() {
+			endSample(messages, key)
+		})
+	}
+	if sample.count < sampleLimit {
+		sample.count++
+		return true
+	}
+	sample.suppressed++
+	return false
+}
+
+func endSample(messages map[string]*sampledMessage, key string) {
+	sampleMutex.Lock()
+	sample := messages[key]
+	delete(messages, key)
+	interval := sampleInterval
+	sampleMutex.Unlock()
+	if sample != nil && sample.suppressed > 0 {
+		Error().Msg(fmt.Sprint(sample.msg, " (", sample.suppressed, " similar messages suppressed in the last ", interval, ")"))
+	}
+}
+
+func sampleKey(msg string) string {
+	builder := strings.Builder{}
+	inNumber := false
+	for i := 0; i < len(msg); i++ {
+		if msg[i] >= '0' && msg[i] <= '9' {
+			if !inNumber {
+				builder.WriteByte('#')
+			}
+			inNumber = true
+			continue
+		}
+		inNumber = false
+		builder.WriteByte(msg[i])
+	}
+	return builder.String()
+}
@@ -1,5 +1,9 @@
 package conf
 
+import "errors"
+
+var errNotModified = errors.New("rules/providers/dnsmasq/conf: Not modified")
+
 type InvalidDomainNameError string
 
 func (e InvalidDomainNameError) Error() string {
@@ -23,3 +27,18 @@ type ReadFileError struct {
 func (e ReadFileError) Error() string {
 	return "rules/providers/dnsmasq/conf: An error occurred while reading dnsmasq conf file \"" + e.filePath + "\" " + e.err.Error()
 }
+
+type FetchURLError struct {
+	url string
+	err error
+}
+
+func (e FetchURLError) Error() string {
+	return "rules/providers/dnsmasq/conf: Failed to fetch dnsmasq conf file from \"" + e.url + "\" " + e.err.Error()
+}
+
+type UnexpectedStatusError string
+
+func (e UnexpectedStatusError) Error() string {
+	return "rules/providers/dnsmasq/conf: Unexpected HTTP status " + string(e)
+}
@@ -7,15 +7,26 @@ import (
 	"github.com/zhouchenh/secDNS/internal/core"
 	"github.com/zhouchenh/secDNS/pkg/rules/provider"
 	"github.com/zhouchenh/secDNS/pkg/upstream/resolver"
+	"io"
+	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 type DnsmasqConf struct {
-	FilePath    string
-	Resolver    resolver.Resolver
-	fileContent []string
-	index       int
+	FilePath        string
+	URL             string
+	RefreshInterval time.Duration
+	Resolver        resolver.Resolver
+	fileContent     []string
+	index           int
+	etag            string
+	lastModified    string
+	refreshHandler  func()
+	refreshOnce     sync.Once
 }
 
 var typeOfDnsmasqConf = descriptor.TypeOfNew(new(*DnsmasqConf))
@@ -35,29 +46,14 @@ func (d *DnsmasqConf) Provide(receive func(name string, r resolver.Resolver), re
 	}
 	canReceiveError := receiveError != nil
 	if d.fileContent == nil {
-		file, err := core.OpenFile(d.FilePath)
-		if err != nil {
-			if canReceiveError {
-				receiveError(OpenFileError{
-					filePath: d.FilePath,
-					err:      err,
-				})
-			}
-			return false
-		}
-		scanner := bufio.NewScanner(file)
-		for scanner.Scan() {
-			d.fileContent = append(d.fileContent, scanner.Text())
-		}
-		if err := scanner.Err(); err != nil {
-			if canReceiveError {
-				receiveError(OpenFileError{
-					filePath: d.FilePath,
-					err:      err,
-				})
-			}
+		fileContent, err := d.read()
+		if err != nil && canReceiveError {
+			receiveError(err)
 		}
+		d.fileContent = fileContent
 		if len(d.fileContent) < 1 {
+			d.fileContent = nil
+			d.startRefreshing()
 			return false
 		}
 	}
@@ -80,7 +76,124 @@ func (d *DnsmasqConf) Provide(receive func(name string, r resolver.Resolver), re
 		d.index++
 		break
 	}
-	return d.index < len(d.fileContent)
+	if d.index < len(d.fileContent) {
+		return true
+	}
+	d.startRefreshing()
+	return false
+}
+
+func (d *DnsmasqConf) SetRefreshHandler(handler func()) {
+	d.refreshHandler = handler
+}
+
+// startRefreshing starts downloading the file from the URL periodically, once
+// all rules have been provided for the first time.
+func (d *DnsmasqConf) startRefreshing() {
+	if d.URL == "" || d.RefreshInterval <= 0 || d.refreshHandler == nil {
+		return
+	}
+	d.refreshOnce.Do(func() {
+		go d.refresh()
+	})
+}
+
+// refresh replaces the rules with the downloaded file whenever it has changed.
+// If downloading fails, the last rules are kept. The rules are provided again
+// by the refresh handler, which is called from this goroutine, so that Provide
+// is never called concurrently.
+func (d *DnsmasqConf) refresh() {
+	for {
+		time.Sleep(d.RefreshInterval)
+		fileContent, err := d.read()
+		if err == errNotModified {
+			continue
+		}
+		if err != nil {
+			common.ErrOutput(err)
+			continue
+		}
+		d.fileContent = fileContent
+		if d.fileContent == nil {
+			d.fileContent = []string{}
+		}
+		d.index = 0
+		d.refreshHandler()
+	}
+}
+
+func (d *DnsmasqConf) read() ([]string, error) {
+	file, err := d.open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var fileContent []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fileContent = append(fileContent, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return fileContent, ReadFileError{
+			filePath: d.location(),
+			err:      err,
+		}
+	}
+	return fileContent, nil
+}
+
+func (d *DnsmasqConf) open() (io.ReadCloser, error) {
+	if d.URL == "" {
+		file, err := core.OpenFile(d.FilePath)
+		if err != nil {
+			return nil, OpenFileError{
+				filePath: d.FilePath,
+				err:      err,
+			}
+		}
+		return file, nil
+	}
+	request, err := http.NewRequest(http.MethodGet, d.URL, nil)
+	if err != nil {
+		return nil, FetchURLError{
+			url: d.URL,
+			err: err,
+		}
+	}
+	if d.etag != "" {
+		request.Header.Set("If-None-Match", d.etag)
+	}
+	if d.lastModified != "" {
+		request.Header.Set("If-Modified-Since", d.lastModified)
+	}
+	response, err := (&http.Client{Timeout: 30 * time.Second}).Do(request)
+	if err != nil {
+		return nil, FetchURLError{
+			url: d.URL,
+			err: err,
+		}
+	}
+	if response.StatusCode == http.StatusNotModified {
+		_ = response.Body.Close()
+		return nil, errNotModified
+	}
+	if response.StatusCode != http.StatusOK {
+		_ = response.Body.Close()
+		return nil, FetchURLError{
+			url: d.URL,
+			err: UnexpectedStatusError(response.Status),
+		}
+	}
+	d.etag = response.Header.Get("ETag")
+	d.lastModified = response.Header.Get("Last-Modified")
+	return response.Body, nil
+}
+
+func (d *DnsmasqConf) location() string {
+	if d.URL != "" {
+		return d.URL
+	}
+	return d.FilePath
 }
 
 func init() {
@@ -89,9 +202,69 @@ func init() {
 		Filler: descriptor.Fillers{
 			descriptor.ObjectFiller{
 				ObjectPath: descriptor.Path{"FilePath"},
-				ValueSource: descriptor.ObjectAtPath{
-					ObjectPath:     descriptor.Path{"filePath"},
-					AssignableKind: descriptor.KindString,
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath:     descriptor.Path{"filePath"},
+						AssignableKind: descriptor.KindString,
+					},
+					descriptor.DefaultValue{Value: ""},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"URL"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"url"},
+						AssignableKind: descriptor.ConvertibleKind{
+							Kind: descriptor.KindString,
+							ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+								str, ok := original.(string)
+								if !ok {
+									return
+								}
+								if !strings.HasPrefix(str, "http://") && !strings.HasPrefix(str, "https://") {
+									return nil, false
+								}
+								return str, true
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: ""},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"RefreshInterval"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"refreshInterval"},
+						AssignableKind: descriptor.AssignableKinds{
+							descriptor.ConvertibleKind{
+								Kind: descriptor.KindFloat64,
+								ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+									num, ok := original.(float64)
+									if !ok || num < 0 {
+										return nil, false
+									}
+									return time.Duration(num * float64(time.Second)), true
+								},
+							},
+							descriptor.ConvertibleKind{
+								Kind: descriptor.KindString,
+								ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+									str, ok := original.(string)
+									if !ok {
+										return
+									}
+									num, err := strconv.ParseFloat(str, 64)
+									if err != nil || num < 0 {
+										return nil, false
+									}
+									return time.Duration(num * float64(time.Second)), true
+								},
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: time.Duration(0)},
 				},
 			},
 			descriptor.ObjectFiller{
@@ -5,8 +5,11 @@ import (
 	"github.com/zhouchenh/go-descriptor"
 	"github.com/zhouchenh/secDNS/internal/common"
 	"github.com/zhouchenh/secDNS/pkg/upstream/resolver"
+	"strings"
 )
 
+const labelPlaceholder = "{label}"
+
 type Alias struct {
 	Alias    string
 	Resolver resolver.Resolver
@@ -26,7 +29,8 @@ func (alias *Alias) Resolve(query *dns.Msg, depth int) (*dns.Msg, error) {
 	if depth < 0 {
 		return nil, resolver.ErrLoopDetected
 	}
-	if alias.Alias == query.Question[0].Name {
+	target := alias.target(query.Question[0].Name)
+	if target == query.Question[0].Name {
 		return nil, ErrAliasSameAsName
 	}
 	msg := new(dns.Msg)
@@ -35,12 +39,15 @@ func (alias *Alias) Resolve(query *dns.Msg, depth int) (*dns.Msg, error) {
 	case dns.TypeCNAME, dns.TypeA, dns.TypeAAAA:
 		msg.Answer = append(msg.Answer, &dns.CNAME{
 			Hdr:    dns.RR_Header{Name: query.Question[0].Name, Rrtype: dns.TypeCNAME, Class: dns.ClassINET, Ttl: 60},
-			Target: alias.Alias,
+			Target: target,
 		})
 		switch qType {
 		case dns.TypeA, dns.TypeAAAA:
 			q := new(dns.Msg)
-			q.SetQuestion(alias.Alias, qType)
+			q.SetQuestion(target, qType)
+			if opt := query.IsEdns0(); opt != nil {
+				q.Extra = append(q.Extra, opt)
+			}
 			r, err := alias.Resolver.Resolve(q, depth-1)
 			if err != nil {
 				return nil, err
@@ -51,6 +58,17 @@ func (alias *Alias) Resolve(query *dns.Msg, depth int) (*dns.Msg, error) {
 	return msg, nil
 }
 
+func (alias *Alias) target(name string) string {
+	if !strings.Contains(alias.Alias, labelPlaceholder) {
+		return alias.Alias
+	}
+	label := name
+	if labels := dns.SplitDomainName(name); len(labels) > 0 {
+		label = labels[0]
+	}
+	return strings.ReplaceAll(alias.Alias, labelPlaceholder, label)
+}
+
 func init() {
 	if err := resolver.RegisterResolver(&descriptor.Descriptor{
 		Type: typeOfAlias,
@@ -0,0 +1,118 @@
+package query
+
+import (
+	"github.com/miekg/dns"
+	"github.com/zhouchenh/go-descriptor"
+	"github.com/zhouchenh/secDNS/internal/common"
+	"github.com/zhouchenh/secDNS/pkg/upstream/resolver"
+)
+
+const (
+	ActionMinimal     = "minimal"
+	ActionRefuse      = "refuse"
+	ActionPassthrough = "passthrough"
+)
+
+type AnyQuery struct {
+	Resolver resolver.Resolver
+	Action   string
+	TTL      uint32
+}
+
+var typeOfAnyQuery = descriptor.TypeOfNew(new(*AnyQuery))
+
+func (aq *AnyQuery) Type() descriptor.Type {
+	return typeOfAnyQuery
+}
+
+func (aq *AnyQuery) TypeName() string {
+	return "anyQuery"
+}
+
+func (aq *AnyQuery) Resolve(query *dns.Msg, depth int) (*dns.Msg, error) {
+	if depth < 0 {
+		return nil, resolver.ErrLoopDetected
+	}
+	if query.Question[0].Qtype != dns.TypeANY || aq.Action == ActionPassthrough {
+		return aq.Resolver.Resolve(query, depth-1)
+	}
+	msg := new(dns.Msg)
+	if aq.Action == ActionRefuse {
+		msg.SetRcode(query, dns.RcodeRefused)
+		return msg, nil
+	}
+	msg.SetReply(query)
+	msg.Answer = append(msg.Answer, &dns.HINFO{
+		Hdr: dns.RR_Header{Name: query.Question[0].Name, Rrtype: dns.TypeHINFO, Class: query.Question[0].Qclass, Ttl: aq.TTL},
+		Cpu: "RFC8482",
+		Os:  "",
+	})
+	return msg, nil
+}
+
+func init() {
+	if err := resolver.RegisterResolver(&descriptor.Descriptor{
+		Type: typeOfAnyQuery,
+		Filler: descriptor.Fillers{
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"Resolver"},
+				ValueSource: descriptor.ObjectAtPath{
+					ObjectPath: descriptor.Path{"resolver"},
+					AssignableKind: descriptor.AssignmentFunction(func(i interface{}) (object interface{}, ok bool) {
+						object, s, f := resolver.Descriptor().Describe(i)
+						ok = s > 0 && f < 1
+						return
+					}),
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"Action"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"action"},
+						AssignableKind: descriptor.ConvertibleKind{
+							Kind: descriptor.KindString,
+							ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+								str, ok := original.(string)
+								if !ok {
+									return
+								}
+								switch str {
+								case ActionMinimal, ActionRefuse, ActionPassthrough:
+									return str, true
+								default:
+									return nil, false
+								}
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: ActionMinimal},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"TTL"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"ttl"},
+						AssignableKind: descriptor.ConvertibleKind{
+							Kind: descriptor.KindFloat64,
+							ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+								num, ok := original.(float64)
+								if !ok {
+									return
+								}
+								if num >= 0 && num <= 4294967295 {
+									return uint32(num), true
+								}
+								return nil, false
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: uint32(3600)},
+				},
+			},
+		},
+	}); err != nil {
+		common.ErrOutput(err)
+	}
+}
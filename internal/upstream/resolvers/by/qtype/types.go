@@ -0,0 +1,171 @@
+package qtype
+
+import (
+	"github.com/miekg/dns"
+	"github.com/zhouchenh/go-descriptor"
+	"github.com/zhouchenh/secDNS/internal/common"
+	"github.com/zhouchenh/secDNS/pkg/upstream/resolver"
+	"strings"
+)
+
+type ByType struct {
+	Branches        []*Branch
+	DefaultResolver resolver.Resolver
+}
+
+type Branch struct {
+	Types    []uint16
+	Resolver resolver.Resolver
+}
+
+var typeOfByType = descriptor.TypeOfNew(new(*ByType))
+
+func (bt *ByType) Type() descriptor.Type {
+	return typeOfByType
+}
+
+func (bt *ByType) TypeName() string {
+	return "byType"
+}
+
+func (bt *ByType) Resolve(query *dns.Msg, depth int) (*dns.Msg, error) {
+	if depth < 0 {
+		return nil, resolver.ErrLoopDetected
+	}
+	qType := query.Question[0].Qtype
+	for _, branch := range bt.Branches {
+		for _, t := range branch.Types {
+			if t == qType {
+				return branch.Resolver.Resolve(query, depth-1)
+			}
+		}
+	}
+	return bt.DefaultResolver.Resolve(query, depth-1)
+}
+
+func parseType(i interface{}) (t uint16, ok bool) {
+	switch value := i.(type) {
+	case string:
+		t, ok = dns.StringToType[strings.ToUpper(value)]
+	case float64:
+		if value >= 0 && value <= 65535 {
+			t, ok = uint16(value), true
+		}
+	}
+	return
+}
+
+var branchDescriptor = descriptor.Descriptor{
+	Type: descriptor.TypeOfNew(new(*Branch)),
+	Filler: descriptor.Fillers{
+		descriptor.ObjectFiller{
+			ObjectPath: descriptor.Path{"Types"},
+			ValueSource: descriptor.ObjectAtPath{
+				ObjectPath: descriptor.Path{"types"},
+				AssignableKind: descriptor.AssignableKinds{
+					descriptor.ConvertibleKind{
+						Kind: descriptor.KindString,
+						ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+							t, ok := parseType(original)
+							if !ok {
+								return
+							}
+							return []uint16{t}, true
+						},
+					},
+					descriptor.ConvertibleKind{
+						Kind: descriptor.KindFloat64,
+						ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+							t, ok := parseType(original)
+							if !ok {
+								return
+							}
+							return []uint16{t}, true
+						},
+					},
+					descriptor.ConvertibleKind{
+						Kind: descriptor.KindSlice,
+						ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+							interfaces, ok := original.([]interface{})
+							if !ok {
+								return
+							}
+							var types []uint16
+							for _, i := range interfaces {
+								t, ok := parseType(i)
+								if !ok {
+									return nil, false
+								}
+								types = append(types, t)
+							}
+							return types, true
+						},
+					},
+				},
+			},
+		},
+		descriptor.ObjectFiller{
+			ObjectPath: descriptor.Path{"Resolver"},
+			ValueSource: descriptor.ObjectAtPath{
+				ObjectPath: descriptor.Path{"resolver"},
+				AssignableKind: descriptor.AssignmentFunction(func(i interface{}) (object interface{}, ok bool) {
+					object, s, f := resolver.Descriptor().Describe(i)
+					ok = s > 0 && f < 1
+					return
+				}),
+			},
+		},
+	},
+}
+
+func init() {
+	if err := resolver.RegisterResolver(&descriptor.Descriptor{
+		Type: typeOfByType,
+		Filler: descriptor.Fillers{
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"Branches"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"branches"},
+						AssignableKind: descriptor.ConvertibleKind{
+							Kind: descriptor.KindSlice,
+							ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+								interfaces, ok := original.([]interface{})
+								if !ok {
+									return
+								}
+								var branches []*Branch
+								for _, i := range interfaces {
+									rawBranch, s, f := branchDescriptor.Describe(i)
+									if s < 1 || f > 0 {
+										return nil, false
+									}
+									branch, ok := rawBranch.(*Branch)
+									if !ok {
+										return nil, false
+									}
+									branches = append(branches, branch)
+								}
+								return branches, true
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: []*Branch(nil)},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"DefaultResolver"},
+				ValueSource: descriptor.ObjectAtPath{
+					ObjectPath: descriptor.Path{"defaultResolver"},
+					AssignableKind: descriptor.AssignmentFunction(func(i interface{}) (object interface{}, ok bool) {
+						object, s, f := resolver.Descriptor().Describe(i)
+						ok = s > 0 && f < 1
+						return
+					}),
+				},
+			},
+		},
+	}); err != nil {
+		common.ErrOutput(err)
+	}
+}
@@ -0,0 +1,101 @@
+package chaos
+
+import (
+	"github.com/miekg/dns"
+	"github.com/zhouchenh/go-descriptor"
+	"github.com/zhouchenh/secDNS/internal/common"
+	"github.com/zhouchenh/secDNS/internal/core"
+	"github.com/zhouchenh/secDNS/pkg/upstream/resolver"
+	"strings"
+)
+
+type Chaos struct {
+	Version  string
+	Hostname string
+	ID       string
+}
+
+var typeOfChaos = descriptor.TypeOfNew(new(*Chaos))
+
+func (c *Chaos) Type() descriptor.Type {
+	return typeOfChaos
+}
+
+func (c *Chaos) TypeName() string {
+	return "chaos"
+}
+
+func (c *Chaos) Resolve(query *dns.Msg, depth int) (*dns.Msg, error) {
+	if depth < 0 {
+		return nil, resolver.ErrLoopDetected
+	}
+	question := query.Question[0]
+	if question.Qclass != dns.ClassCHAOS || (question.Qtype != dns.TypeTXT && question.Qtype != dns.TypeANY) {
+		return refused(query), nil
+	}
+	var txt string
+	switch strings.ToLower(question.Name) {
+	case "version.bind.", "version.server.":
+		txt = c.Version
+	case "hostname.bind.":
+		txt = c.Hostname
+	case "id.server.":
+		txt = c.ID
+	}
+	if txt == "" {
+		return refused(query), nil
+	}
+	msg := new(dns.Msg)
+	msg.SetReply(query)
+	msg.Answer = append(msg.Answer, &dns.TXT{
+		Hdr: dns.RR_Header{Name: question.Name, Rrtype: dns.TypeTXT, Class: dns.ClassCHAOS, Ttl: 0},
+		Txt: []string{txt},
+	})
+	return msg, nil
+}
+
+func refused(query *dns.Msg) *dns.Msg {
+	msg := new(dns.Msg)
+	msg.SetRcode(query, dns.RcodeRefused)
+	return msg
+}
+
+func init() {
+	if err := resolver.RegisterResolver(&descriptor.Descriptor{
+		Type: typeOfChaos,
+		Filler: descriptor.Fillers{
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"Version"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath:     descriptor.Path{"version"},
+						AssignableKind: descriptor.KindString,
+					},
+					descriptor.DefaultValue{Value: core.VersionStatement()[0]},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"Hostname"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath:     descriptor.Path{"hostname"},
+						AssignableKind: descriptor.KindString,
+					},
+					descriptor.DefaultValue{Value: ""},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"ID"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath:     descriptor.Path{"id"},
+						AssignableKind: descriptor.KindString,
+					},
+					descriptor.DefaultValue{Value: ""},
+				},
+			},
+		},
+	}); err != nil {
+		common.ErrOutput(err)
+	}
+}
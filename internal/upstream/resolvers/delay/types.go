@@ -0,0 +1,107 @@
+package delay
+
+import (
+	"github.com/miekg/dns"
+	"github.com/zhouchenh/go-descriptor"
+	"github.com/zhouchenh/secDNS/internal/common"
+	"github.com/zhouchenh/secDNS/pkg/upstream/resolver"
+	"math/rand"
+	"strconv"
+	"time"
+)
+
+type Delay struct {
+	Resolver resolver.Resolver
+	MinDelay time.Duration
+	MaxDelay time.Duration
+}
+
+var typeOfDelay = descriptor.TypeOfNew(new(*Delay))
+
+func (d *Delay) Type() descriptor.Type {
+	return typeOfDelay
+}
+
+func (d *Delay) TypeName() string {
+	return "delay"
+}
+
+func (d *Delay) Resolve(query *dns.Msg, depth int) (*dns.Msg, error) {
+	if depth < 0 {
+		return nil, resolver.ErrLoopDetected
+	}
+	delay := d.MinDelay
+	if d.MaxDelay > d.MinDelay {
+		delay += time.Duration(rand.Int63n(int64(d.MaxDelay - d.MinDelay + 1)))
+	}
+	reply, err := d.Resolver.Resolve(query, depth-1)
+	time.Sleep(delay)
+	return reply, err
+}
+
+func init() {
+	convertibleKindDuration := descriptor.AssignableKinds{
+		descriptor.ConvertibleKind{
+			Kind: descriptor.KindFloat64,
+			ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+				num, ok := original.(float64)
+				if !ok || num < 0 {
+					return nil, false
+				}
+				return time.Duration(num * float64(time.Second)), true
+			},
+		},
+		descriptor.ConvertibleKind{
+			Kind: descriptor.KindString,
+			ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+				str, ok := original.(string)
+				if !ok {
+					return
+				}
+				num, err := strconv.ParseFloat(str, 64)
+				if err != nil || num < 0 {
+					return nil, false
+				}
+				return time.Duration(num * float64(time.Second)), true
+			},
+		},
+	}
+	if err := resolver.RegisterResolver(&descriptor.Descriptor{
+		Type: typeOfDelay,
+		Filler: descriptor.Fillers{
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"Resolver"},
+				ValueSource: descriptor.ObjectAtPath{
+					ObjectPath: descriptor.Path{"resolver"},
+					AssignableKind: descriptor.AssignmentFunction(func(i interface{}) (object interface{}, ok bool) {
+						object, s, f := resolver.Descriptor().Describe(i)
+						ok = s > 0 && f < 1
+						return
+					}),
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"MinDelay"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath:     descriptor.Path{"minDelay"},
+						AssignableKind: convertibleKindDuration,
+					},
+					descriptor.DefaultValue{Value: time.Duration(0)},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"MaxDelay"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath:     descriptor.Path{"maxDelay"},
+						AssignableKind: convertibleKindDuration,
+					},
+					descriptor.DefaultValue{Value: time.Duration(0)},
+				},
+			},
+		},
+	}); err != nil {
+		common.ErrOutput(err)
+	}
+}
@@ -0,0 +1,5 @@
+package dns64
+
+import "errors"
+
+var ErrInvalidPrefix = errors.New("upstream/resolvers/dns64: Prefix has bits set beyond prefix length")
@@ -11,6 +11,7 @@ import (
 type DNS64 struct {
 	Resolver           resolver.Resolver
 	Prefix             net.IP
+	PrefixLength       int
 	IgnoreExistingAAAA bool
 }
 
@@ -30,6 +31,9 @@ func (d *DNS64) Resolve(query *dns.Msg, depth int) (*dns.Msg, error) {
 	}
 	switch qType := query.Question[0].Qtype; qType {
 	case dns.TypeAAAA:
+		if !d.isValidPrefix() {
+			return nil, ErrInvalidPrefix
+		}
 		if d.IgnoreExistingAAAA {
 			return d.dns64(query, depth)
 		} else {
@@ -74,13 +78,35 @@ func (d *DNS64) aToAAAA(a *dns.A) *dns.AAAA {
 	}
 }
 
+// ipv4ToIPv6 embeds the IPv4 address right after the prefix, skipping bits
+// 64 to 71 (the "u" octet), as described in RFC 6052 section 2.2.
 func (d *DNS64) ipv4ToIPv6(ipv4 net.IP) net.IP {
 	ipv6 := make(net.IP, net.IPv6len)
-	copy(ipv6, d.Prefix[0:12])
-	copy(ipv6[12:16], ipv4.To4())
+	copy(ipv6, d.Prefix[0:d.PrefixLength/8])
+	i := d.PrefixLength / 8
+	for _, b := range ipv4.To4() {
+		if i == 8 {
+			i++
+		}
+		ipv6[i] = b
+		i++
+	}
 	return ipv6
 }
 
+// isValidPrefix reports whether the prefix has no bits set past the prefix
+// length, nor in the "u" octet, which RFC 6052 section 2.2 requires to be zero
+// for every prefix length.
+func (d *DNS64) isValidPrefix() bool {
+	if len(d.Prefix) != net.IPv6len {
+		return false
+	}
+	if d.Prefix[8] != 0 {
+		return false
+	}
+	return d.Prefix.Mask(net.CIDRMask(d.PrefixLength, 128)).Equal(d.Prefix)
+}
+
 func isNoErrorReply(reply *dns.Msg) bool {
 	return reply != nil && reply.Response && reply.Rcode == dns.RcodeSuccess
 }
@@ -134,6 +160,32 @@ func init() {
 					descriptor.DefaultValue{Value: net.IP{0, 0x64, 0xff, 0x9b, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}},
 				},
 			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"PrefixLength"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"prefixLength"},
+						AssignableKind: descriptor.ConvertibleKind{
+							Kind: descriptor.KindFloat64,
+							ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+								num, ok := original.(float64)
+								if !ok {
+									return
+								}
+								switch length := int(num); {
+								case float64(length) != num:
+									return nil, false
+								case length == 32, length == 40, length == 48, length == 56, length == 64, length == 96:
+									return length, true
+								default:
+									return nil, false
+								}
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: 96},
+				},
+			},
 			descriptor.ObjectFiller{
 				ObjectPath: descriptor.Path{"IgnoreExistingAAAA"},
 				ValueSource: descriptor.ValueSources{
@@ -0,0 +1,61 @@
+package dns64
+
+import (
+	"net"
+	"testing"
+)
+
+// The examples of RFC 6052 section 2.4, embedding 192.0.2.33.
+var embeddingTests = []struct {
+	prefix       string
+	prefixLength int
+	ipv6         string
+}{
+	{"2001:db8::", 32, "2001:db8:c000:221::"},
+	{"2001:db8:100::", 40, "2001:db8:1c0:2:21::"},
+	{"2001:db8:122::", 48, "2001:db8:122:c000:2:2100::"},
+	{"2001:db8:122:300::", 56, "2001:db8:122:3c0:0:221::"},
+	{"2001:db8:122:344::", 64, "2001:db8:122:344:c0:2:2100:0"},
+	{"2001:db8:122:344::", 96, "2001:db8:122:344::192.0.2.33"},
+	{"64:ff9b::", 96, "64:ff9b::192.0.2.33"},
+}
+
+func TestIPv4ToIPv6(t *testing.T) {
+	ipv4 := net.ParseIP("192.0.2.33")
+	for _, test := range embeddingTests {
+		d := &DNS64{Prefix: net.ParseIP(test.prefix), PrefixLength: test.prefixLength}
+		if !d.isValidPrefix() {
+			t.Errorf("%s/%d: prefix rejected", test.prefix, test.prefixLength)
+			continue
+		}
+		ipv6 := d.ipv4ToIPv6(ipv4)
+		if want := net.ParseIP(test.ipv6); !ipv6.Equal(want) {
+			t.Errorf("%s/%d: got %s, want %s", test.prefix, test.prefixLength, ipv6, want)
+		}
+		if ipv6[8] != 0 {
+			t.Errorf("%s/%d: u-octet of %s is not zero", test.prefix, test.prefixLength, ipv6)
+		}
+	}
+}
+
+func TestIsValidPrefix(t *testing.T) {
+	tests := []struct {
+		prefix       string
+		prefixLength int
+	}{
+		// Bits set past the prefix length.
+		{"2001:db8:1::", 32},
+		{"2001:db8:122:344::", 48},
+		{"2001:db8:122:344::1", 64},
+		{"64:ff9b::1", 96},
+		// Bits set in the u-octet.
+		{"2001:db8:122:344:100::", 64},
+		{"2001:db8:122:344:100::", 96},
+	}
+	for _, test := range tests {
+		d := &DNS64{Prefix: net.ParseIP(test.prefix), PrefixLength: test.prefixLength}
+		if d.isValidPrefix() {
+			t.Errorf("%s/%d: prefix accepted", test.prefix, test.prefixLength)
+		}
+	}
+}
@@ -1,11 +1,23 @@
 package doh
 
-import "errors"
+import (
+	"errors"
+	"net/http"
+)
 
-var ErrResolverNotReady = errors.New("upstream/resolvers/doh: Resolver not ready")
+var (
+	ErrResolverNotReady = errors.New("upstream/resolvers/doh: Resolver not ready")
+	ErrReplyMismatch    = errors.New("upstream/resolvers/doh: Reply does not match query")
+)
 
 type UnknownHostError string
 
 func (e UnknownHostError) Error() string {
 	return "upstream/resolvers/doh: Cannot resolve " + string(e)
 }
+
+type ReservedHeaderError string
+
+func (e ReservedHeaderError) Error() string {
+	return "upstream/resolvers/doh: Header " + http.CanonicalHeaderKey(string(e)) + " is reserved and cannot be configured"
+}
@@ -3,6 +3,7 @@ package doh
 import (
 	"bytes"
 	"crypto/tls"
+	"encoding/base64"
 	"github.com/miekg/dns"
 	"github.com/zhouchenh/go-descriptor"
 	"github.com/zhouchenh/secDNS/internal/common"
@@ -12,27 +13,39 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
 
 type DoH struct {
-	URL            *url.URL
-	QueryTimeout   time.Duration
-	TlsServerName  string
-	SendThrough    net.IP
-	Resolver       resolver.Resolver
-	Socks5Proxy    string
-	Socks5Username string
-	Socks5Password string
-	queryClient    *client
-	initializing   bool
+	URL                 *url.URL
+	UseGET              bool
+	QueryTimeout        time.Duration
+	TlsServerName       string
+	PinnedSPKI          [][]byte
+	SendThrough         net.IP
+	BindInterface       string
+	Resolver            resolver.Resolver
+	Socks5Proxy         string
+	Socks5Username      string
+	Socks5Password      string
+	StripEDNSOptions    []uint16
+	KeepOnlyEDNSOptions []uint16
+	VerifyReply         bool
+	StaticURLs          bool
+	URLRefreshInterval  time.Duration
+	URLRefreshByTTL     bool
+	Headers             http.Header
+	queryClient         *client
+	initializing        bool
 }
 
 type client struct {
 	httpClient   *http.Client
 	serverName   string
 	resolvedURLs []string
+	urlMutex     sync.RWMutex
 }
 
 var typeOfDoH = descriptor.TypeOfNew(new(*DoH))
@@ -57,26 +70,29 @@ func (d *DoH) Resolve(query *dns.Msg, depth int) (*dns.Msg, error) {
 		d.initClient()
 		d.initializing = false
 	}
-	wireFormattedQuery, e := query.Pack()
+	wireFormattedQuery, e := common.SelectEDNS0Options(query, d.StripEDNSOptions, d.KeepOnlyEDNSOptions).Pack()
 	if e != nil {
 		return nil, e
 	}
+	resolvedURLs := d.queryClient.getResolvedURLs()
 	once := new(sync.Once)
 	msg := make(chan *dns.Msg)
 	err := make(chan error)
-	errCollector := make(chan error, len(d.queryClient.resolvedURLs))
+	errCollector := make(chan error, len(resolvedURLs))
 	wg := new(sync.WaitGroup)
-	wg.Add(len(d.queryClient.resolvedURLs))
+	wg.Add(len(resolvedURLs))
 	sendRequest := func(urlString string) {
-		request, e := http.NewRequest(http.MethodPost, urlString, bytes.NewReader(wireFormattedQuery))
+		request, e := d.newRequest(urlString, wireFormattedQuery)
 		if e != nil {
 			errCollector <- e
 			wg.Done()
 			return
 		}
+		for key, values := range d.Headers {
+			request.Header[key] = values
+		}
 		request.Host = d.queryClient.serverName
 		request.Header.Set("Accept", "application/dns-message")
-		request.Header.Set("Content-Type", "application/dns-message")
 		response, e := d.queryClient.httpClient.Do(request)
 		if e != nil {
 			errCollector <- e
@@ -87,6 +103,9 @@ func (d *DoH) Resolve(query *dns.Msg, depth int) (*dns.Msg, error) {
 		response.Body.Close()
 		m := new(dns.Msg)
 		e = m.Unpack(wireFormattedMsg)
+		if e == nil && d.VerifyReply && !common.QuestionMatches(query, m) {
+			e = ErrReplyMismatch
+		}
 		if e != nil {
 			errCollector <- e
 			wg.Done()
@@ -98,13 +117,25 @@ func (d *DoH) Resolve(query *dns.Msg, depth int) (*dns.Msg, error) {
 		})
 		wg.Done()
 	}
-	for _, urlString := range d.queryClient.resolvedURLs {
+	for _, urlString := range resolvedURLs {
 		go sendRequest(urlString)
 	}
 	go func() {
 		wg.Wait()
 		once.Do(func() {
-			resolvedURLs := d.resolveURL(depth - 1)
+			if d.StaticURLs {
+				msg <- nil
+				if len(errCollector) < 1 {
+					err <- UnknownHostError(d.URL.Hostname())
+					return
+				}
+				for len(errCollector) > 1 {
+					<-errCollector
+				}
+				err <- <-errCollector
+				return
+			}
+			resolvedURLs, _ := d.resolveURL(depth - 1)
 			if len(resolvedURLs) < 1 {
 				if len(errCollector) < 1 {
 					msg <- nil
@@ -112,7 +143,7 @@ func (d *DoH) Resolve(query *dns.Msg, depth int) (*dns.Msg, error) {
 					return
 				}
 			} else {
-				d.queryClient.resolvedURLs = resolvedURLs
+				d.queryClient.setResolvedURLs(resolvedURLs)
 				if len(errCollector) < 1 {
 					m, e := d.Resolve(query, depth-1)
 					msg <- m
@@ -132,9 +163,39 @@ func (d *DoH) Resolve(query *dns.Msg, depth int) (*dns.Msg, error) {
 
 func (d *DoH) NameServerResolver() {}
 
+// isReservedHeader reports whether key is a header which is required by the
+// DoH protocol or managed by the HTTP client, and cannot be configured.
+func isReservedHeader(key string) bool {
+	switch http.CanonicalHeaderKey(key) {
+	case "Accept", "Content-Type", "Content-Length", "Host", "Transfer-Encoding", "Connection":
+		return true
+	default:
+		return false
+	}
+}
+
+func (d *DoH) newRequest(urlString string, wireFormattedQuery []byte) (*http.Request, error) {
+	if !d.UseGET {
+		request, err := http.NewRequest(http.MethodPost, urlString, bytes.NewReader(wireFormattedQuery))
+		if err != nil {
+			return nil, err
+		}
+		request.Header.Set("Content-Type", "application/dns-message")
+		return request, nil
+	}
+	u, err := url.Parse(urlString)
+	if err != nil {
+		return nil, err
+	}
+	values := u.Query()
+	values.Set("dns", base64.RawURLEncoding.EncodeToString(wireFormattedQuery))
+	u.RawQuery = values.Encode()
+	return http.NewRequest(http.MethodGet, u.String(), nil)
+}
+
 func (d *DoH) initClient() {
 	serverName := d.serverName()
-	resolvedURLs := d.resolveURL(64)
+	resolvedURLs, ttl := d.resolveURL(64)
 	var proxyFunc func(*http.Request) (*url.URL, error)
 	if d.Socks5Proxy != "" {
 		var user *url.Userinfo
@@ -155,10 +216,12 @@ func (d *DoH) initClient() {
 			Transport: &http.Transport{
 				DialContext: (&net.Dialer{
 					LocalAddr: &net.TCPAddr{IP: d.SendThrough},
+					Control:   common.BindToDevice(d.BindInterface),
 				}).DialContext,
 				Proxy: proxyFunc,
 				TLSClientConfig: &tls.Config{
-					ServerName: serverName,
+					ServerName:       serverName,
+					VerifyConnection: common.VerifySPKIPins(d.PinnedSPKI),
 				},
 			},
 			Timeout: d.QueryTimeout,
@@ -166,6 +229,39 @@ func (d *DoH) initClient() {
 		serverName:   serverName,
 		resolvedURLs: resolvedURLs,
 	}
+	if d.URLRefreshInterval > 0 || d.URLRefreshByTTL {
+		go d.refreshURLs(ttl)
+	}
+}
+
+func (d *DoH) refreshURLs(ttl time.Duration) {
+	for {
+		interval := d.URLRefreshInterval
+		if d.URLRefreshByTTL && ttl > 0 && (interval <= 0 || ttl < interval) {
+			interval = ttl
+		}
+		if interval <= 0 {
+			return
+		}
+		time.Sleep(interval)
+		resolvedURLs, newTTL := d.resolveURL(64)
+		if len(resolvedURLs) > 0 {
+			d.queryClient.setResolvedURLs(resolvedURLs)
+			ttl = newTTL
+		}
+	}
+}
+
+func (c *client) getResolvedURLs() []string {
+	c.urlMutex.RLock()
+	defer c.urlMutex.RUnlock()
+	return c.resolvedURLs
+}
+
+func (c *client) setResolvedURLs(resolvedURLs []string) {
+	c.urlMutex.Lock()
+	c.resolvedURLs = resolvedURLs
+	c.urlMutex.Unlock()
 }
 
 func (d *DoH) serverName() string {
@@ -178,7 +274,10 @@ func (d *DoH) serverName() string {
 	return ""
 }
 
-func (d *DoH) resolveURL(resolutionDepth int) (resolvedURLs []string) {
+// resolveURL also returns the lowest TTL of the A records the URLs are
+// resolved from, which is at least one second, or 0 if no domain name is
+// resolved.
+func (d *DoH) resolveURL(resolutionDepth int) (resolvedURLs []string, ttl time.Duration) {
 	if d.URL == nil {
 		return
 	}
@@ -194,6 +293,7 @@ func (d *DoH) resolveURL(resolutionDepth int) (resolvedURLs []string) {
 		if err != nil {
 			return
 		}
+		hasTTL := false
 		for _, rawRecord := range reply.Answer {
 			record, ok := rawRecord.(*dns.A)
 			if !ok {
@@ -208,11 +308,49 @@ func (d *DoH) resolveURL(resolutionDepth int) (resolvedURLs []string) {
 			}
 			urlStruct.Host = host
 			resolvedURLs = append(resolvedURLs, (&urlStruct).String())
+			if recordTTL := time.Duration(record.Hdr.Ttl) * time.Second; !hasTTL || recordTTL < ttl {
+				ttl = recordTTL
+				hasTTL = true
+			}
+		}
+		if hasTTL && ttl < time.Second {
+			ttl = time.Second
+		}
+	}
+	return
+}
+
+// parseURLTemplate strips the RFC 8484 "dns" variable from a URI template such
+// as "https://dns.example/dns-query{?dns}", reporting whether it was present.
+func parseURLTemplate(template string) (urlString string, isTemplate bool, ok bool) {
+	urlString = template
+	for _, variable := range []string{"{?dns}", "{&dns}"} {
+		if strings.HasSuffix(urlString, variable) {
+			urlString = strings.TrimSuffix(urlString, variable)
+			isTemplate = true
+			break
 		}
 	}
+	ok = !strings.ContainsAny(urlString, "{}")
 	return
 }
 
+func convertEDNS0OptionCodes(original interface{}) (converted interface{}, ok bool) {
+	interfaces, ok := original.([]interface{})
+	if !ok {
+		return
+	}
+	codes := make([]uint16, 0, len(interfaces))
+	for _, i := range interfaces {
+		num, ok := i.(float64)
+		if !ok || num < 0 || num > 65535 || num != float64(uint16(num)) {
+			return nil, false
+		}
+		codes = append(codes, uint16(num))
+	}
+	return codes, true
+}
+
 func init() {
 	if err := resolver.RegisterResolver(&descriptor.Descriptor{
 		Type: typeOfDoH,
@@ -228,6 +366,10 @@ func init() {
 							if !ok {
 								return
 							}
+							str, _, ok = parseURLTemplate(str)
+							if !ok {
+								return
+							}
 							converted, err := url.Parse(str)
 							ok = err == nil
 							return
@@ -235,6 +377,23 @@ func init() {
 					},
 				},
 			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"UseGET"},
+				ValueSource: descriptor.ObjectAtPath{
+					ObjectPath: descriptor.Path{"url"},
+					AssignableKind: descriptor.ConvertibleKind{
+						Kind: descriptor.KindString,
+						ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+							str, ok := original.(string)
+							if !ok {
+								return
+							}
+							_, isTemplate, ok := parseURLTemplate(str)
+							return isTemplate, ok
+						},
+					},
+				},
+			},
 			descriptor.ObjectFiller{
 				ObjectPath: descriptor.Path{"QueryTimeout"},
 				ValueSource: descriptor.ValueSources{
@@ -280,6 +439,53 @@ func init() {
 					descriptor.DefaultValue{Value: ""},
 				},
 			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"PinnedSPKI"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"pinnedSPKI"},
+						AssignableKind: descriptor.AssignableKinds{
+							descriptor.ConvertibleKind{
+								Kind: descriptor.KindString,
+								ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+									str, ok := original.(string)
+									if !ok {
+										return
+									}
+									pin, ok := common.ParseSPKIPin(str)
+									if !ok {
+										return
+									}
+									return [][]byte{pin}, true
+								},
+							},
+							descriptor.ConvertibleKind{
+								Kind: descriptor.KindSlice,
+								ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+									interfaces, ok := original.([]interface{})
+									if !ok {
+										return
+									}
+									var pins [][]byte
+									for _, i := range interfaces {
+										str, ok := i.(string)
+										if !ok {
+											return nil, false
+										}
+										pin, ok := common.ParseSPKIPin(str)
+										if !ok {
+											return nil, false
+										}
+										pins = append(pins, pin)
+									}
+									return pins, true
+								},
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: [][]byte(nil)},
+				},
+			},
 			descriptor.ObjectFiller{
 				ObjectPath: descriptor.Path{"SendThrough"},
 				ValueSource: descriptor.ValueSources{
@@ -301,6 +507,28 @@ func init() {
 					descriptor.DefaultValue{Value: nil},
 				},
 			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"BindInterface"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"bindInterface"},
+						AssignableKind: descriptor.ConvertibleKind{
+							Kind: descriptor.KindString,
+							ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+								str, ok := original.(string)
+								if !ok {
+									return
+								}
+								if str != "" && !common.BindToDeviceSupported {
+									return nil, false
+								}
+								return str, true
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: ""},
+				},
+			},
 			descriptor.ObjectFiller{
 				ObjectPath: descriptor.Path{"Resolver"},
 				ValueSource: descriptor.ValueSources{
@@ -351,6 +579,190 @@ func init() {
 					descriptor.DefaultValue{Value: ""},
 				},
 			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"StripEDNSOptions"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"stripEDNSOptions"},
+						AssignableKind: descriptor.ConvertibleKind{
+							Kind:            descriptor.KindSlice,
+							ConvertFunction: convertEDNS0OptionCodes,
+						},
+					},
+					descriptor.DefaultValue{Value: []uint16(nil)},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"KeepOnlyEDNSOptions"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"keepOnlyEDNSOptions"},
+						AssignableKind: descriptor.ConvertibleKind{
+							Kind:            descriptor.KindSlice,
+							ConvertFunction: convertEDNS0OptionCodes,
+						},
+					},
+					descriptor.DefaultValue{Value: []uint16(nil)},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"VerifyReply"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"verifyReply"},
+						AssignableKind: descriptor.AssignableKinds{
+							descriptor.KindBool,
+							descriptor.ConvertibleKind{
+								Kind: descriptor.KindString,
+								ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+									str, ok := original.(string)
+									if !ok {
+										return
+									}
+									switch str {
+									case "true":
+										return true, true
+									case "false":
+										return false, true
+									default:
+										return
+									}
+								},
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: true},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"StaticURLs"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"staticURLs"},
+						AssignableKind: descriptor.AssignableKinds{
+							descriptor.KindBool,
+							descriptor.ConvertibleKind{
+								Kind: descriptor.KindString,
+								ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+									str, ok := original.(string)
+									if !ok {
+										return
+									}
+									switch str {
+									case "true":
+										return true, true
+									case "false":
+										return false, true
+									default:
+										return
+									}
+								},
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: false},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"URLRefreshInterval"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"urlRefreshInterval"},
+						AssignableKind: descriptor.AssignableKinds{
+							descriptor.ConvertibleKind{
+								Kind: descriptor.KindFloat64,
+								ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+									num, ok := original.(float64)
+									if !ok || num < 0 {
+										return nil, false
+									}
+									return time.Duration(num * float64(time.Second)), true
+								},
+							},
+							descriptor.ConvertibleKind{
+								Kind: descriptor.KindString,
+								ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+									str, ok := original.(string)
+									if !ok {
+										return
+									}
+									num, err := strconv.ParseFloat(str, 64)
+									if err != nil || num < 0 {
+										return nil, false
+									}
+									return time.Duration(num * float64(time.Second)), true
+								},
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: time.Duration(0)},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"URLRefreshByTTL"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"urlRefreshByTTL"},
+						AssignableKind: descriptor.AssignableKinds{
+							descriptor.KindBool,
+							descriptor.ConvertibleKind{
+								Kind: descriptor.KindString,
+								ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+									str, ok := original.(string)
+									if !ok {
+										return
+									}
+									switch str {
+									case "true":
+										return true, true
+									case "false":
+										return false, true
+									default:
+										return
+									}
+								},
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: false},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"Headers"},
+				ValueSource: descriptor.ObjectAtPath{
+					ObjectPath: descriptor.Root,
+					AssignableKind: descriptor.AssignmentFunction(func(i interface{}) (object interface{}, ok bool) {
+						config, ok := i.(map[string]interface{})
+						if !ok {
+							return
+						}
+						original, exists := config["headers"]
+						if !exists {
+							return http.Header(nil), true
+						}
+						m, ok := original.(map[string]interface{})
+						if !ok {
+							return nil, false
+						}
+						headers := make(http.Header)
+						for key, i := range m {
+							value, ok := i.(string)
+							if !ok {
+								return nil, false
+							}
+							// Reserved headers would be overwritten when sending
+							// requests, so reject them rather than silently
+							// ignoring them.
+							if isReservedHeader(key) {
+								common.ErrOutput(ReservedHeaderError(key))
+								return nil, false
+							}
+							headers.Add(key, value)
+						}
+						return headers, true
+					}),
+				},
+			},
 		},
 	}); err != nil {
 		common.ErrOutput(err)
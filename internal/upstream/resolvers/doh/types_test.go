@@ -0,0 +1,72 @@
+package doh
+
+import (
+	"github.com/miekg/dns"
+	"github.com/zhouchenh/go-descriptor"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// noAddress replies to every query without an answer.
+type noAddress struct{}
+
+func (noAddress) Type() descriptor.Type { return nil }
+func (noAddress) TypeName() string      { return "noAddress" }
+
+func (noAddress) Resolve(query *dns.Msg, depth int) (*dns.Msg, error) {
+	msg := new(dns.Msg)
+	msg.SetReply(query)
+	return msg, nil
+}
+
+func TestVerifyReply(t *testing.T) {
+	mismatches := []struct {
+		name   string
+		mutate func(reply *dns.Msg)
+	}{
+		{"qname", func(reply *dns.Msg) { reply.Question[0].Name = "other.example." }},
+		{"qtype", func(reply *dns.Msg) { reply.Question[0].Qtype = dns.TypeAAAA }},
+		{"qclass", func(reply *dns.Msg) { reply.Question[0].Qclass = dns.ClassCHAOS }},
+		{"question count", func(reply *dns.Msg) { reply.Question = nil }},
+	}
+	for _, mismatch := range mismatches {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			query := new(dns.Msg)
+			if err := query.Unpack(body); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			reply := new(dns.Msg)
+			reply.SetReply(query)
+			mismatch.mutate(reply)
+			wireFormattedReply, _ := reply.Pack()
+			w.Header().Set("Content-Type", "application/dns-message")
+			_, _ = w.Write(wireFormattedReply)
+		}))
+		serverURL, _ := url.Parse(server.URL)
+		for _, verifyReply := range []bool{true, false} {
+			d := &DoH{
+				URL:          serverURL,
+				QueryTimeout: 2 * time.Second,
+				Resolver:     noAddress{},
+				StaticURLs:   true,
+				VerifyReply:  verifyReply,
+			}
+			query := new(dns.Msg)
+			query.SetQuestion("example.com.", dns.TypeA)
+			reply, err := d.Resolve(query, 1)
+			switch {
+			case verifyReply && err != ErrReplyMismatch:
+				t.Errorf("%s mismatch: Resolve() error = %v, want %v", mismatch.name, err, ErrReplyMismatch)
+			case !verifyReply && (err != nil || reply == nil):
+				t.Errorf("%s mismatch without verifyReply: Resolve() error = %v, want the reply", mismatch.name, err)
+			}
+		}
+		server.Close()
+	}
+}
@@ -0,0 +1,157 @@
+package types
+
+import (
+	"github.com/miekg/dns"
+	"github.com/zhouchenh/go-descriptor"
+	"github.com/zhouchenh/secDNS/internal/common"
+	"github.com/zhouchenh/secDNS/pkg/upstream/resolver"
+	"strings"
+)
+
+type DropTypes struct {
+	Resolver resolver.Resolver
+	Types    []uint16
+}
+
+var typeOfDropTypes = descriptor.TypeOfNew(new(*DropTypes))
+
+func (dt *DropTypes) Type() descriptor.Type {
+	return typeOfDropTypes
+}
+
+func (dt *DropTypes) TypeName() string {
+	return "dropTypes"
+}
+
+func (dt *DropTypes) Resolve(query *dns.Msg, depth int) (*dns.Msg, error) {
+	if depth < 0 {
+		return nil, resolver.ErrLoopDetected
+	}
+	reply, err := dt.Resolver.Resolve(query, depth-1)
+	if err != nil {
+		return nil, err
+	}
+	var minTTL uint32
+	dropped := false
+	answer := common.FilterResourceRecords(reply.Answer, func(rr dns.RR) bool {
+		t := rr.Header().Rrtype
+		if sig, ok := rr.(*dns.RRSIG); ok {
+			t = sig.TypeCovered
+		}
+		if !dt.isDropped(t) {
+			return true
+		}
+		if !dropped || rr.Header().Ttl < minTTL {
+			minTTL = rr.Header().Ttl
+		}
+		dropped = true
+		return false
+	})
+	if !dropped {
+		return reply, nil
+	}
+	reply.Answer = answer
+	if len(reply.Answer) > 0 || reply.Rcode != dns.RcodeSuccess {
+		return reply, nil
+	}
+	// The reply is now a NODATA response. Make sure it carries an SOA record,
+	// so that it can be cached negatively no longer than the dropped records.
+	reply.Ns = common.FilterResourceRecords(reply.Ns, func(rr dns.RR) bool {
+		return rr.Header().Rrtype != dns.TypeNS
+	})
+	for _, rr := range reply.Ns {
+		if rr.Header().Rrtype == dns.TypeSOA {
+			return reply, nil
+		}
+	}
+	reply.Ns = append(reply.Ns, common.SyntheticSOA(query.Question[0].Name, minTTL))
+	return reply, nil
+}
+
+func (dt *DropTypes) isDropped(t uint16) bool {
+	for _, droppedType := range dt.Types {
+		if t == droppedType {
+			return true
+		}
+	}
+	return false
+}
+
+func parseType(i interface{}) (t uint16, ok bool) {
+	switch value := i.(type) {
+	case string:
+		t, ok = dns.StringToType[strings.ToUpper(value)]
+	case float64:
+		if value >= 0 && value <= 65535 {
+			t, ok = uint16(value), true
+		}
+	}
+	return
+}
+
+func init() {
+	if err := resolver.RegisterResolver(&descriptor.Descriptor{
+		Type: typeOfDropTypes,
+		Filler: descriptor.Fillers{
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"Resolver"},
+				ValueSource: descriptor.ObjectAtPath{
+					ObjectPath: descriptor.Path{"resolver"},
+					AssignableKind: descriptor.AssignmentFunction(func(i interface{}) (object interface{}, ok bool) {
+						object, s, f := resolver.Descriptor().Describe(i)
+						ok = s > 0 && f < 1
+						return
+					}),
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"Types"},
+				ValueSource: descriptor.ObjectAtPath{
+					ObjectPath: descriptor.Path{"types"},
+					AssignableKind: descriptor.AssignableKinds{
+						descriptor.ConvertibleKind{
+							Kind: descriptor.KindString,
+							ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+								t, ok := parseType(original)
+								if !ok {
+									return
+								}
+								return []uint16{t}, true
+							},
+						},
+						descriptor.ConvertibleKind{
+							Kind: descriptor.KindFloat64,
+							ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+								t, ok := parseType(original)
+								if !ok {
+									return
+								}
+								return []uint16{t}, true
+							},
+						},
+						descriptor.ConvertibleKind{
+							Kind: descriptor.KindSlice,
+							ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+								interfaces, ok := original.([]interface{})
+								if !ok {
+									return
+								}
+								var types []uint16
+								for _, i := range interfaces {
+									t, ok := parseType(i)
+									if !ok {
+										return nil, false
+									}
+									types = append(types, t)
+								}
+								return types, true
+							},
+						},
+					},
+				},
+			},
+		},
+	}); err != nil {
+		common.ErrOutput(err)
+	}
+}
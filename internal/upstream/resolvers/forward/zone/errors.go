@@ -0,0 +1,14 @@
+package zone
+
+import "errors"
+
+var (
+	ErrNilResolver       = NilPointerError("resolver")
+	ErrNoAvailableServer = errors.New("upstream/resolvers/forward/zone: No available server")
+)
+
+type NilPointerError string
+
+func (e NilPointerError) Error() string {
+	return "upstream/resolvers/forward/zone: Nil " + string(e)
+}
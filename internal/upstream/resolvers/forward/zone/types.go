@@ -0,0 +1,169 @@
+package zone
+
+import (
+	"github.com/miekg/dns"
+	"github.com/zhouchenh/go-descriptor"
+	"github.com/zhouchenh/secDNS/internal/common"
+	"github.com/zhouchenh/secDNS/pkg/upstream/resolver"
+	"net"
+	"strings"
+	"sync/atomic"
+)
+
+type ForwardZone struct {
+	Zone     string
+	Servers  []resolver.Resolver
+	Resolver resolver.Resolver
+	current  uint32
+}
+
+var typeOfForwardZone = descriptor.TypeOfNew(new(*ForwardZone))
+
+func (fz *ForwardZone) Type() descriptor.Type {
+	return typeOfForwardZone
+}
+
+func (fz *ForwardZone) TypeName() string {
+	return "forwardZone"
+}
+
+func (fz *ForwardZone) Resolve(query *dns.Msg, depth int) (*dns.Msg, error) {
+	if depth < 0 {
+		return nil, resolver.ErrLoopDetected
+	}
+	if !dns.IsSubDomain(fz.Zone, strings.ToLower(query.Question[0].Name)) {
+		if fz.Resolver == nil {
+			return nil, ErrNilResolver
+		}
+		return fz.Resolver.Resolve(query, depth-1)
+	}
+	if len(fz.Servers) < 1 {
+		return nil, ErrNoAvailableServer
+	}
+	// Start with the server which replied last time, so that a failed server
+	// only delays queries until another one replies.
+	current := int(atomic.LoadUint32(&fz.current))
+	var msg *dns.Msg
+	var err error
+	for i := 0; i < len(fz.Servers); i++ {
+		index := (current + i) % len(fz.Servers)
+		reply, e := fz.Servers[index].Resolve(query, depth-1)
+		if e != nil {
+			err = e
+			continue
+		}
+		msg, err = reply, nil
+		if reply.Rcode == dns.RcodeServerFailure || reply.Rcode == dns.RcodeRefused {
+			continue
+		}
+		atomic.StoreUint32(&fz.current, uint32(index))
+		return reply, nil
+	}
+	return msg, err
+}
+
+// describeServer builds a nameServer resolver from an address, such as
+// "192.0.2.53" or "[2001:db8::53]:5353", or from a nameServer
+// ResolverConfigObject.
+func describeServer(i interface{}) (r resolver.Resolver, ok bool) {
+	config := i
+	if str, isString := i.(string); isString {
+		if ip := net.ParseIP(str); ip != nil {
+			config = map[string]interface{}{"address": str}
+		} else {
+			host, port, err := net.SplitHostPort(str)
+			if err != nil || net.ParseIP(host) == nil {
+				return nil, false
+			}
+			config = map[string]interface{}{"address": host, "port": port}
+		}
+	}
+	if _, isMap := config.(map[string]interface{}); !isMap {
+		return nil, false
+	}
+	object, s, f := resolver.Descriptor().Describe(map[string]interface{}{"type": "nameServer", "config": config})
+	if s < 1 || f > 0 {
+		return nil, false
+	}
+	r, ok = object.(resolver.Resolver)
+	return
+}
+
+func init() {
+	if err := resolver.RegisterResolver(&descriptor.Descriptor{
+		Type: typeOfForwardZone,
+		Filler: descriptor.Fillers{
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"Zone"},
+				ValueSource: descriptor.ObjectAtPath{
+					ObjectPath: descriptor.Path{"zone"},
+					AssignableKind: descriptor.ConvertibleKind{
+						Kind: descriptor.KindString,
+						ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+							str, ok := original.(string)
+							if !ok {
+								return
+							}
+							if _, ok := dns.IsDomainName(str); !ok {
+								return nil, false
+							}
+							return strings.ToLower(dns.Fqdn(str)), true
+						},
+					},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"Servers"},
+				ValueSource: descriptor.ObjectAtPath{
+					ObjectPath: descriptor.Path{"servers"},
+					AssignableKind: descriptor.AssignableKinds{
+						descriptor.ConvertibleKind{
+							Kind: descriptor.KindString,
+							ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+								r, ok := describeServer(original)
+								if !ok {
+									return
+								}
+								return []resolver.Resolver{r}, true
+							},
+						},
+						descriptor.ConvertibleKind{
+							Kind: descriptor.KindSlice,
+							ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+								interfaces, ok := original.([]interface{})
+								if !ok || len(interfaces) < 1 {
+									return nil, false
+								}
+								var servers []resolver.Resolver
+								for _, i := range interfaces {
+									r, ok := describeServer(i)
+									if !ok {
+										return nil, false
+									}
+									servers = append(servers, r)
+								}
+								return servers, true
+							},
+						},
+					},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"Resolver"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"resolver"},
+						AssignableKind: descriptor.AssignmentFunction(func(i interface{}) (object interface{}, ok bool) {
+							object, s, f := resolver.Descriptor().Describe(i)
+							ok = s > 0 && f < 1
+							return
+						}),
+					},
+					descriptor.DefaultValue{Value: nil},
+				},
+			},
+		},
+	}); err != nil {
+		common.ErrOutput(err)
+	}
+}
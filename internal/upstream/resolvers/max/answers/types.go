@@ -0,0 +1,185 @@
+package answers
+
+import (
+	"github.com/miekg/dns"
+	"github.com/zhouchenh/go-descriptor"
+	"github.com/zhouchenh/secDNS/internal/common"
+	"github.com/zhouchenh/secDNS/pkg/upstream/resolver"
+	"strconv"
+	"strings"
+)
+
+type MaxAnswers struct {
+	Resolver resolver.Resolver
+	Max      int
+	Types    []uint16
+}
+
+var typeOfMaxAnswers = descriptor.TypeOfNew(new(*MaxAnswers))
+
+func (ma *MaxAnswers) Type() descriptor.Type {
+	return typeOfMaxAnswers
+}
+
+func (ma *MaxAnswers) TypeName() string {
+	return "maxAnswers"
+}
+
+func (ma *MaxAnswers) Resolve(query *dns.Msg, depth int) (*dns.Msg, error) {
+	if depth < 0 {
+		return nil, resolver.ErrLoopDetected
+	}
+	reply, err := ma.Resolver.Resolve(query, depth-1)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[uint16]int)
+	truncated := make(map[uint16]bool)
+	answer := common.FilterResourceRecords(reply.Answer, func(rr dns.RR) bool {
+		t := rr.Header().Rrtype
+		if !ma.isLimited(t) {
+			return true
+		}
+		if counts[t] >= ma.Max {
+			truncated[t] = true
+			return false
+		}
+		counts[t]++
+		return true
+	})
+	if len(truncated) < 1 {
+		return reply, nil
+	}
+	// Signatures of truncated RRsets no longer validate, so remove them.
+	reply.Answer = common.FilterResourceRecords(answer, func(rr dns.RR) bool {
+		sig, ok := rr.(*dns.RRSIG)
+		return !ok || !truncated[sig.TypeCovered]
+	})
+	return reply, nil
+}
+
+func (ma *MaxAnswers) isLimited(t uint16) bool {
+	for _, limitedType := range ma.Types {
+		if t == limitedType {
+			return true
+		}
+	}
+	return false
+}
+
+func parseType(i interface{}) (t uint16, ok bool) {
+	switch value := i.(type) {
+	case string:
+		t, ok = dns.StringToType[strings.ToUpper(value)]
+	case float64:
+		if value >= 0 && value <= 65535 {
+			t, ok = uint16(value), true
+		}
+	}
+	return
+}
+
+func init() {
+	if err := resolver.RegisterResolver(&descriptor.Descriptor{
+		Type: typeOfMaxAnswers,
+		Filler: descriptor.Fillers{
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"Resolver"},
+				ValueSource: descriptor.ObjectAtPath{
+					ObjectPath: descriptor.Path{"resolver"},
+					AssignableKind: descriptor.AssignmentFunction(func(i interface{}) (object interface{}, ok bool) {
+						object, s, f := resolver.Descriptor().Describe(i)
+						ok = s > 0 && f < 1
+						return
+					}),
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"Max"},
+				ValueSource: descriptor.ObjectAtPath{
+					ObjectPath: descriptor.Path{"max"},
+					AssignableKind: descriptor.AssignableKinds{
+						descriptor.ConvertibleKind{
+							Kind: descriptor.KindFloat64,
+							ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+								num, ok := original.(float64)
+								if !ok {
+									return
+								}
+								if num >= 1 && num <= 65535 {
+									return int(num), true
+								}
+								return nil, false
+							},
+						},
+						descriptor.ConvertibleKind{
+							Kind: descriptor.KindString,
+							ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+								str, ok := original.(string)
+								if !ok {
+									return
+								}
+								i, err := strconv.ParseUint(str, 10, 16)
+								if err != nil || i < 1 {
+									return nil, false
+								}
+								return int(i), true
+							},
+						},
+					},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"Types"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"types"},
+						AssignableKind: descriptor.AssignableKinds{
+							descriptor.ConvertibleKind{
+								Kind: descriptor.KindString,
+								ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+									t, ok := parseType(original)
+									if !ok {
+										return
+									}
+									return []uint16{t}, true
+								},
+							},
+							descriptor.ConvertibleKind{
+								Kind: descriptor.KindFloat64,
+								ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+									t, ok := parseType(original)
+									if !ok {
+										return
+									}
+									return []uint16{t}, true
+								},
+							},
+							descriptor.ConvertibleKind{
+								Kind: descriptor.KindSlice,
+								ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+									interfaces, ok := original.([]interface{})
+									if !ok {
+										return
+									}
+									var types []uint16
+									for _, i := range interfaces {
+										t, ok := parseType(i)
+										if !ok {
+											return nil, false
+										}
+										types = append(types, t)
+									}
+									return types, true
+								},
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: []uint16{dns.TypeA, dns.TypeAAAA}},
+				},
+			},
+		},
+	}); err != nil {
+		common.ErrOutput(err)
+	}
+}
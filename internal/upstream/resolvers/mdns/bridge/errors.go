@@ -0,0 +1,9 @@
+package bridge
+
+var ErrNilResolver = NilPointerError("resolver")
+
+type NilPointerError string
+
+func (e NilPointerError) Error() string {
+	return "upstream/resolvers/mdns/bridge: Nil " + string(e)
+}
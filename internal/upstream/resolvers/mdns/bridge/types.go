@@ -0,0 +1,198 @@
+package bridge
+
+import (
+	"github.com/miekg/dns"
+	"github.com/zhouchenh/go-descriptor"
+	"github.com/zhouchenh/secDNS/internal/common"
+	"github.com/zhouchenh/secDNS/pkg/upstream/resolver"
+	"golang.org/x/net/ipv4"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var mdnsGroup = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+type MDNSBridge struct {
+	Interface *net.Interface
+	Timeout   time.Duration
+	Resolver  resolver.Resolver
+}
+
+var typeOfMDNSBridge = descriptor.TypeOfNew(new(*MDNSBridge))
+
+func (mb *MDNSBridge) Type() descriptor.Type {
+	return typeOfMDNSBridge
+}
+
+func (mb *MDNSBridge) TypeName() string {
+	return "mdnsBridge"
+}
+
+func (mb *MDNSBridge) Resolve(query *dns.Msg, depth int) (*dns.Msg, error) {
+	if depth < 0 {
+		return nil, resolver.ErrLoopDetected
+	}
+	question := query.Question[0]
+	if !dns.IsSubDomain("local.", strings.ToLower(question.Name)) {
+		if mb.Resolver == nil {
+			return nil, ErrNilResolver
+		}
+		return mb.Resolver.Resolve(query, depth-1)
+	}
+	response, err := mb.exchange(question)
+	if err != nil {
+		return nil, err
+	}
+	msg := new(dns.Msg)
+	msg.SetReply(query)
+	if response == nil {
+		msg.Rcode = dns.RcodeNameError
+		return msg, nil
+	}
+	for _, rr := range response.Answer {
+		header := rr.Header()
+		if !strings.EqualFold(header.Name, question.Name) {
+			continue
+		}
+		if header.Rrtype != question.Qtype && header.Rrtype != dns.TypeCNAME && question.Qtype != dns.TypeANY {
+			continue
+		}
+		// Clear the cache-flush bit, which only has a meaning in mDNS.
+		header.Class &^= 1 << 15
+		msg.Answer = append(msg.Answer, rr)
+	}
+	return msg, nil
+}
+
+// exchange sends a one-shot multicast DNS query (RFC 6762 section 5.1) from an
+// ephemeral port, and waits for the first response answering it. It returns a
+// nil message if no response is received before the timeout.
+func (mb *MDNSBridge) exchange(question dns.Question) (*dns.Msg, error) {
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if mb.Interface != nil {
+		if err := ipv4.NewPacketConn(conn).SetMulticastInterface(mb.Interface); err != nil {
+			return nil, err
+		}
+	}
+	query := new(dns.Msg)
+	query.SetQuestion(question.Name, question.Qtype)
+	query.RecursionDesired = false
+	wireFormattedQuery, err := query.Pack()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.WriteTo(wireFormattedQuery, mdnsGroup); err != nil {
+		return nil, err
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(mb.Timeout))
+	buffer := make([]byte, dns.MaxMsgSize)
+	for {
+		n, _, err := conn.ReadFrom(buffer)
+		if err != nil {
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				return nil, nil
+			}
+			return nil, err
+		}
+		response := new(dns.Msg)
+		if response.Unpack(buffer[:n]) != nil || !response.Response || response.Id != query.Id {
+			continue
+		}
+		for _, rr := range response.Answer {
+			if strings.EqualFold(rr.Header().Name, question.Name) {
+				return response, nil
+			}
+		}
+	}
+}
+
+func init() {
+	if err := resolver.RegisterResolver(&descriptor.Descriptor{
+		Type: typeOfMDNSBridge,
+		Filler: descriptor.Fillers{
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"Interface"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"interface"},
+						AssignableKind: descriptor.ConvertibleKind{
+							Kind: descriptor.KindString,
+							ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+								str, ok := original.(string)
+								if !ok {
+									return
+								}
+								if str == "" {
+									return (*net.Interface)(nil), true
+								}
+								i, err := net.InterfaceByName(str)
+								if err != nil {
+									return nil, false
+								}
+								return i, true
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: (*net.Interface)(nil)},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"Timeout"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"timeout"},
+						AssignableKind: descriptor.AssignableKinds{
+							descriptor.ConvertibleKind{
+								Kind: descriptor.KindFloat64,
+								ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+									num, ok := original.(float64)
+									if !ok {
+										return
+									}
+									return time.Duration(num * float64(time.Second)), true
+								},
+							},
+							descriptor.ConvertibleKind{
+								Kind: descriptor.KindString,
+								ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+									str, ok := original.(string)
+									if !ok {
+										return
+									}
+									num, err := strconv.ParseFloat(str, 64)
+									if err != nil {
+										return nil, false
+									}
+									return time.Duration(num * float64(time.Second)), true
+								},
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: time.Second},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"Resolver"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"resolver"},
+						AssignableKind: descriptor.AssignmentFunction(func(i interface{}) (object interface{}, ok bool) {
+							object, s, f := resolver.Descriptor().Describe(i)
+							ok = s > 0 && f < 1
+							return
+						}),
+					},
+					descriptor.DefaultValue{Value: nil},
+				},
+			},
+		},
+	}); err != nil {
+		common.ErrOutput(err)
+	}
+}
@@ -0,0 +1,12 @@
+package nameserver
+
+import "errors"
+
+var (
+	ErrQuestionCaseMismatch = errors.New("upstream/resolvers/nameserver: Question case mismatch")
+	ErrTsigMissing          = errors.New("upstream/resolvers/nameserver: TSIG missing in reply")
+	ErrPoisonedReply        = errors.New("upstream/resolvers/nameserver: Only poisoned replies received")
+	ErrReplyMismatch        = errors.New("upstream/resolvers/nameserver: Reply does not match query")
+	ErrTooManyQueries       = errors.New("upstream/resolvers/nameserver: Too many concurrent queries")
+	ErrReferral             = errors.New("upstream/resolvers/nameserver: Referral received from a non-recursive server")
+)
@@ -2,34 +2,65 @@ package nameserver
 
 import (
 	"crypto/tls"
+	"encoding/base64"
+	"errors"
 	"github.com/miekg/dns"
 	"github.com/txthinking/socks5"
 	"github.com/zhouchenh/go-descriptor"
 	"github.com/zhouchenh/secDNS/internal/common"
 	"github.com/zhouchenh/secDNS/pkg/upstream/resolver"
+	"math/rand"
 	"net"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 )
 
+const sourcePortAttempts = 8
+
+const (
+	OnReferralPass  = "pass"
+	OnReferralLog   = "log"
+	OnReferralError = "error"
+)
+
 type NameServer struct {
-	Address        net.IP
-	Port           uint16
-	Protocol       string
-	QueryTimeout   time.Duration
-	TlsServerName  string
-	SendThrough    net.IP
-	Socks5Proxy    string
-	Socks5Username string
-	Socks5Password string
-	queryClient    *client
+	Address             net.IP
+	Port                uint16
+	Protocol            string
+	QueryTimeout        time.Duration
+	DialTimeout         time.Duration
+	TlsServerName       string
+	PinnedSPKI          [][]byte
+	SendThrough         net.IP
+	BindInterface       string
+	SourcePortRange     [2]uint16
+	Socks5Proxy         string
+	Socks5Username      string
+	Socks5Password      string
+	DNS0x20             bool
+	VerifyReply         bool
+	PoisonedNetworks    []*net.IPNet
+	TsigKeyName         string
+	TsigAlgorithm       string
+	TsigSecret          string
+	MaxConcurrency      uint
+	StripEDNSOptions    []uint16
+	KeepOnlyEDNSOptions []uint16
+	OnReferral          string
+	queryClient         *client
+	semaphore           chan struct{}
+	tcpQueryClient      *client
+	initOnce            sync.Once
 }
 
 type client struct {
-	dialFunc     func(network, address string) (conn net.Conn, err error)
-	dialTLSFunc  func(network, address string) (conn net.Conn, err error)
-	socks5Client *socks5.Client
+	dialFunc        func(network, address string) (conn net.Conn, err error)
+	dialTLSFunc     func(network, address string) (conn net.Conn, err error)
+	socks5Client    *socks5.Client
+	sourcePortRange [2]uint16
 	*dns.Client
 }
 
@@ -47,14 +78,102 @@ func (ns *NameServer) Resolve(query *dns.Msg, depth int) (*dns.Msg, error) {
 	if depth < 0 {
 		return nil, resolver.ErrLoopDetected
 	}
-	if ns.queryClient == nil {
-		ns.initClient()
+	ns.initOnce.Do(ns.initClient)
+	if semaphore := ns.semaphore; semaphore != nil {
+		timer := time.NewTimer(ns.QueryTimeout)
+		select {
+		case semaphore <- struct{}{}:
+			timer.Stop()
+			defer func() { <-semaphore }()
+		case <-timer.C:
+			return nil, ErrTooManyQueries
+		}
 	}
-	connection, err := ns.queryClient.Dial(net.JoinHostPort(ns.Address.String(), strconv.Itoa(int(ns.Port))))
+	outgoingQuery := common.SelectEDNS0Options(query, ns.StripEDNSOptions, ns.KeepOnlyEDNSOptions)
+	if ns.DNS0x20 {
+		if outgoingQuery == query {
+			outgoingQuery = query.Copy()
+		}
+		outgoingQuery.Question[0].Name = randomizeCase(query.Question[0].Name)
+	}
+	msg, err := ns.exchange(ns.queryClient, outgoingQuery)
+	if err != nil {
+		return nil, err
+	}
+	if msg.Truncated && ns.tcpQueryClient != nil {
+		msg, err = ns.exchange(ns.tcpQueryClient, outgoingQuery)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if ns.DNS0x20 {
+		if len(msg.Question) != 1 || msg.Question[0].Name != outgoingQuery.Question[0].Name {
+			return nil, ErrQuestionCaseMismatch
+		}
+		restoreCase(msg, query.Question[0].Name)
+	}
+	if ns.OnReferral != OnReferralPass && query.RecursionDesired && isReferral(msg) {
+		if ns.OnReferral == OnReferralError {
+			return nil, ErrReferral
+		}
+		common.ErrOutput(ErrReferral, ": ", net.JoinHostPort(ns.Address.String(), strconv.Itoa(int(ns.Port))), " for ", query.Question[0].Name)
+	}
+	return msg, nil
+}
+
+// isReferral reports whether msg is a referral from a server which does not
+// provide recursion, such as an authoritative server, rather than an answer.
+func isReferral(msg *dns.Msg) bool {
+	if msg.RecursionAvailable || msg.Rcode != dns.RcodeSuccess || len(msg.Answer) > 0 {
+		return false
+	}
+	for _, rr := range msg.Ns {
+		if rr.Header().Rrtype == dns.TypeNS {
+			return true
+		}
+	}
+	return false
+}
+
+func (ns *NameServer) NameServerResolver() {}
+
+func randomizeCase(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		if ('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z') && rand.Intn(2) == 0 {
+			b[i] = c ^ 0x20
+		}
+	}
+	return string(b)
+}
+
+func restoreCase(msg *dns.Msg, name string) {
+	msg.Question[0].Name = name
+	for _, section := range [][]dns.RR{msg.Answer, msg.Ns, msg.Extra} {
+		for _, rr := range section {
+			if header := rr.Header(); strings.EqualFold(header.Name, name) {
+				header.Name = name
+			}
+		}
+	}
+}
+
+func (ns *NameServer) exchange(c *client, query *dns.Msg) (*dns.Msg, error) {
+	connection, err := c.Dial(net.JoinHostPort(ns.Address.String(), strconv.Itoa(int(ns.Port))))
 	if err != nil {
 		return nil, err
 	}
 	defer connection.Close()
+	if opt := query.IsEdns0(); opt != nil {
+		connection.UDPSize = opt.UDPSize()
+	}
+	if ns.TsigKeyName != "" {
+		connection.TsigSecret = map[string]string{ns.TsigKeyName: ns.TsigSecret}
+		// Signing removes the TSIG record from the message, so sign a copy
+		// for every exchange, including the retry over TCP.
+		query = query.Copy()
+		query.SetTsig(ns.TsigKeyName, ns.TsigAlgorithm, 300, time.Now().Unix())
+	}
 	_ = connection.SetDeadline(time.Now().Add(ns.QueryTimeout))
 	if err := connection.WriteMsg(query); err != nil {
 		return nil, err
@@ -63,14 +182,67 @@ func (ns *NameServer) Resolve(query *dns.Msg, depth int) (*dns.Msg, error) {
 	if err != nil {
 		return nil, err
 	}
+	if ns.isPoisoned(msg) {
+		// Forged replies usually arrive before the genuine one, so keep
+		// listening on UDP until the query times out.
+		if _, isPacketConn := connection.Conn.(net.PacketConn); !isPacketConn {
+			return nil, ErrPoisonedReply
+		}
+		for ns.isPoisoned(msg) {
+			if msg, err = connection.ReadMsg(); err != nil {
+				return nil, ErrPoisonedReply
+			}
+		}
+	}
+	if ns.VerifyReply && (msg.Id != query.Id || !common.QuestionMatches(query, msg)) {
+		return nil, ErrReplyMismatch
+	}
+	if ns.TsigKeyName != "" {
+		if msg.IsTsig() == nil {
+			return nil, ErrTsigMissing
+		}
+		msg.Extra = msg.Extra[:len(msg.Extra)-1]
+	}
 	return msg, nil
 }
 
-func (ns *NameServer) NameServerResolver() {}
+func (ns *NameServer) isPoisoned(msg *dns.Msg) bool {
+	if len(ns.PoisonedNetworks) < 1 {
+		return false
+	}
+	for _, rr := range msg.Answer {
+		var ip net.IP
+		switch record := rr.(type) {
+		case *dns.A:
+			ip = record.A
+		case *dns.AAAA:
+			ip = record.AAAA
+		default:
+			continue
+		}
+		for _, network := range ns.PoisonedNetworks {
+			if network.Contains(ip) {
+				return true
+			}
+		}
+	}
+	return false
+}
 
 func (ns *NameServer) initClient() {
+	if ns.MaxConcurrency > 0 {
+		ns.semaphore = make(chan struct{}, ns.MaxConcurrency)
+	}
+	ns.queryClient = ns.newClient(ns.Protocol)
+	switch ns.Protocol {
+	case "udp", "udp4", "udp6":
+		ns.tcpQueryClient = ns.newClient(strings.Replace(ns.Protocol, "udp", "tcp", 1))
+	}
+}
+
+func (ns *NameServer) newClient(protocol string) *client {
 	var addr net.Addr
-	switch strings.TrimSuffix(ns.Protocol, "-tls") {
+	switch strings.TrimSuffix(protocol, "-tls") {
 	case "tcp":
 		addr = &net.TCPAddr{IP: ns.SendThrough}
 	case "udp":
@@ -79,16 +251,19 @@ func (ns *NameServer) initClient() {
 		addr = nil
 	}
 	c := &client{
-		dialFunc:     nil,
-		socks5Client: nil,
+		dialFunc:        nil,
+		socks5Client:    nil,
+		sourcePortRange: ns.SourcePortRange,
 		Client: &dns.Client{
-			Net: ns.Protocol,
+			Net: protocol,
 			TLSConfig: &tls.Config{
-				ServerName: ns.TlsServerName,
+				ServerName:       ns.TlsServerName,
+				VerifyConnection: common.VerifySPKIPins(ns.PinnedSPKI),
 			},
 			Dialer: &net.Dialer{
 				LocalAddr: addr,
-				Timeout:   ns.QueryTimeout,
+				Timeout:   ns.dialTimeout(),
+				Control:   common.BindToDevice(ns.BindInterface),
 			},
 		},
 	}
@@ -112,12 +287,25 @@ func (ns *NameServer) initClient() {
 			return
 		}
 	} else {
-		c.dialFunc = c.Dialer.Dial
+		c.dialFunc = func(network, address string) (conn net.Conn, err error) {
+			return c.dialFromSourcePort(network, func(dialer *net.Dialer) (net.Conn, error) {
+				return dialer.Dial(network, address)
+			})
+		}
 		c.dialTLSFunc = func(network, address string) (conn net.Conn, err error) {
-			return tls.DialWithDialer(c.Dialer, network, address, c.TLSConfig)
+			return c.dialFromSourcePort(network, func(dialer *net.Dialer) (net.Conn, error) {
+				return tls.DialWithDialer(dialer, network, address, c.TLSConfig)
+			})
 		}
 	}
-	ns.queryClient = c
+	return c
+}
+
+func (ns *NameServer) dialTimeout() time.Duration {
+	if ns.DialTimeout > 0 {
+		return ns.DialTimeout
+	}
+	return ns.QueryTimeout
 }
 
 func (ns *NameServer) socks5Timeout(timeout time.Duration) int {
@@ -128,6 +316,33 @@ func (ns *NameServer) socks5Timeout(timeout time.Duration) int {
 	return int(d)
 }
 
+func (c *client) dialFromSourcePort(network string, dial func(dialer *net.Dialer) (net.Conn, error)) (conn net.Conn, err error) {
+	if c.sourcePortRange[0] == 0 {
+		return dial(c.Dialer)
+	}
+	var ip net.IP
+	switch addr := c.Dialer.LocalAddr.(type) {
+	case *net.TCPAddr:
+		ip = addr.IP
+	case *net.UDPAddr:
+		ip = addr.IP
+	}
+	for i := 0; i < sourcePortAttempts; i++ {
+		port := int(c.sourcePortRange[0]) + rand.Intn(int(c.sourcePortRange[1]-c.sourcePortRange[0])+1)
+		dialer := *c.Dialer
+		if strings.HasPrefix(network, "tcp") {
+			dialer.LocalAddr = &net.TCPAddr{IP: ip, Port: port}
+		} else {
+			dialer.LocalAddr = &net.UDPAddr{IP: ip, Port: port}
+		}
+		conn, err = dial(&dialer)
+		if err == nil || !errors.Is(err, syscall.EADDRINUSE) {
+			return
+		}
+	}
+	return dial(c.Dialer)
+}
+
 func (c *client) Dial(address string) (conn *dns.Conn, err error) {
 	network := c.Net
 	if network == "" {
@@ -148,6 +363,72 @@ func (c *client) Dial(address string) (conn *dns.Conn, err error) {
 	return conn, nil
 }
 
+func parseNetworks(original interface{}) (networks []*net.IPNet, ok bool) {
+	var interfaces []interface{}
+	switch value := original.(type) {
+	case string:
+		interfaces = []interface{}{value}
+	case []interface{}:
+		interfaces = value
+	default:
+		return nil, false
+	}
+	for _, i := range interfaces {
+		str, ok := i.(string)
+		if !ok {
+			return nil, false
+		}
+		if ip := common.ParseIPv4v6(str); ip != nil {
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(str)
+		if err != nil {
+			return nil, false
+		}
+		networks = append(networks, network)
+	}
+	return networks, true
+}
+
+func parsePortRange(first, last interface{}) (portRange [2]uint16, ok bool) {
+	for i, bound := range []interface{}{first, last} {
+		var port int
+		switch value := bound.(type) {
+		case float64:
+			port = int(value)
+		case string:
+			var err error
+			if port, err = strconv.Atoi(strings.TrimSpace(value)); err != nil {
+				return portRange, false
+			}
+		default:
+			return portRange, false
+		}
+		if port < 1 || port > 65535 {
+			return portRange, false
+		}
+		portRange[i] = uint16(port)
+	}
+	return portRange, portRange[0] <= portRange[1]
+}
+
+func convertEDNS0OptionCodes(original interface{}) (converted interface{}, ok bool) {
+	interfaces, ok := original.([]interface{})
+	if !ok {
+		return
+	}
+	codes := make([]uint16, 0, len(interfaces))
+	for _, i := range interfaces {
+		num, ok := i.(float64)
+		if !ok || num < 0 || num > 65535 || num != float64(uint16(num)) {
+			return nil, false
+		}
+		codes = append(codes, uint16(num))
+	}
+	return codes, true
+}
+
 func init() {
 	convertibleKindIP := descriptor.ConvertibleKind{
 		Kind: descriptor.KindString,
@@ -258,6 +539,41 @@ func init() {
 					descriptor.DefaultValue{Value: 2 * time.Second},
 				},
 			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"DialTimeout"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"dialTimeout"},
+						AssignableKind: descriptor.AssignableKinds{
+							descriptor.ConvertibleKind{
+								Kind: descriptor.KindFloat64,
+								ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+									num, ok := original.(float64)
+									if !ok {
+										return
+									}
+									return time.Duration(num * float64(time.Second)), true
+								},
+							},
+							descriptor.ConvertibleKind{
+								Kind: descriptor.KindString,
+								ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+									str, ok := original.(string)
+									if !ok {
+										return
+									}
+									num, err := strconv.ParseFloat(str, 64)
+									if err != nil {
+										return nil, false
+									}
+									return time.Duration(num * float64(time.Second)), true
+								},
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: time.Duration(0)},
+				},
+			},
 			descriptor.ObjectFiller{
 				ObjectPath: descriptor.Path{"TlsServerName"},
 				ValueSource: descriptor.ValueSources{
@@ -268,6 +584,53 @@ func init() {
 					descriptor.DefaultValue{Value: ""},
 				},
 			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"PinnedSPKI"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"pinnedSPKI"},
+						AssignableKind: descriptor.AssignableKinds{
+							descriptor.ConvertibleKind{
+								Kind: descriptor.KindString,
+								ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+									str, ok := original.(string)
+									if !ok {
+										return
+									}
+									pin, ok := common.ParseSPKIPin(str)
+									if !ok {
+										return
+									}
+									return [][]byte{pin}, true
+								},
+							},
+							descriptor.ConvertibleKind{
+								Kind: descriptor.KindSlice,
+								ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+									interfaces, ok := original.([]interface{})
+									if !ok {
+										return
+									}
+									var pins [][]byte
+									for _, i := range interfaces {
+										str, ok := i.(string)
+										if !ok {
+											return nil, false
+										}
+										pin, ok := common.ParseSPKIPin(str)
+										if !ok {
+											return nil, false
+										}
+										pins = append(pins, pin)
+									}
+									return pins, true
+								},
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: [][]byte(nil)},
+				},
+			},
 			descriptor.ObjectFiller{
 				ObjectPath: descriptor.Path{"SendThrough"},
 				ValueSource: descriptor.ValueSources{
@@ -278,6 +641,63 @@ func init() {
 					descriptor.DefaultValue{Value: nil},
 				},
 			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"BindInterface"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"bindInterface"},
+						AssignableKind: descriptor.ConvertibleKind{
+							Kind: descriptor.KindString,
+							ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+								str, ok := original.(string)
+								if !ok {
+									return
+								}
+								if str != "" && !common.BindToDeviceSupported {
+									return nil, false
+								}
+								return str, true
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: ""},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"SourcePortRange"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"sourcePortRange"},
+						AssignableKind: descriptor.AssignableKinds{
+							descriptor.ConvertibleKind{
+								Kind: descriptor.KindString,
+								ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+									str, ok := original.(string)
+									if !ok {
+										return
+									}
+									bounds := strings.SplitN(str, "-", 2)
+									if len(bounds) != 2 {
+										return nil, false
+									}
+									return parsePortRange(bounds[0], bounds[1])
+								},
+							},
+							descriptor.ConvertibleKind{
+								Kind: descriptor.KindSlice,
+								ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+									bounds, ok := original.([]interface{})
+									if !ok || len(bounds) != 2 {
+										return nil, false
+									}
+									return parsePortRange(bounds[0], bounds[1])
+								},
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: [2]uint16{}},
+				},
+			},
 			descriptor.ObjectFiller{
 				ObjectPath: descriptor.Path{"Socks5Proxy"},
 				ValueSource: descriptor.ValueSources{
@@ -308,6 +728,223 @@ func init() {
 					descriptor.DefaultValue{Value: ""},
 				},
 			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"TsigKeyName"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"tsigKeyName"},
+						AssignableKind: descriptor.ConvertibleKind{
+							Kind: descriptor.KindString,
+							ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+								str, ok := original.(string)
+								if !ok {
+									return
+								}
+								if str == "" {
+									return "", true
+								}
+								if _, ok = dns.IsDomainName(str); !ok {
+									return
+								}
+								return dns.CanonicalName(str), true
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: ""},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"TsigAlgorithm"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"tsigAlgorithm"},
+						AssignableKind: descriptor.ConvertibleKind{
+							Kind: descriptor.KindString,
+							ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+								str, ok := original.(string)
+								if !ok {
+									return
+								}
+								switch algorithm := dns.CanonicalName(str); algorithm {
+								case dns.HmacSHA1, dns.HmacSHA224, dns.HmacSHA256, dns.HmacSHA384, dns.HmacSHA512:
+									return algorithm, true
+								default:
+									return nil, false
+								}
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: dns.HmacSHA256},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"TsigSecret"},
+				ValueSource: descriptor.ObjectAtPath{
+					ObjectPath: descriptor.Root,
+					AssignableKind: descriptor.AssignmentFunction(func(i interface{}) (object interface{}, ok bool) {
+						m, ok := i.(map[string]interface{})
+						if !ok {
+							return
+						}
+						// The secret is only used with a key name, but then it
+						// must be given, since signing with an empty or
+						// malformed secret would fail every query at runtime.
+						if keyName, _ := m["tsigKeyName"].(string); keyName == "" {
+							return "", true
+						}
+						secret, ok := m["tsigSecret"].(string)
+						if !ok || secret == "" {
+							return nil, false
+						}
+						if _, err := base64.StdEncoding.DecodeString(secret); err != nil {
+							return nil, false
+						}
+						return secret, true
+					}),
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"MaxConcurrency"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"maxConcurrency"},
+						AssignableKind: descriptor.ConvertibleKind{
+							Kind: descriptor.KindFloat64,
+							ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+								num, ok := original.(float64)
+								if !ok {
+									return
+								}
+								if num >= 0 && num == float64(uint(num)) {
+									return uint(num), true
+								}
+								return nil, false
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: uint(0)},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"StripEDNSOptions"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"stripEDNSOptions"},
+						AssignableKind: descriptor.ConvertibleKind{
+							Kind:            descriptor.KindSlice,
+							ConvertFunction: convertEDNS0OptionCodes,
+						},
+					},
+					descriptor.DefaultValue{Value: []uint16(nil)},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"KeepOnlyEDNSOptions"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"keepOnlyEDNSOptions"},
+						AssignableKind: descriptor.ConvertibleKind{
+							Kind:            descriptor.KindSlice,
+							ConvertFunction: convertEDNS0OptionCodes,
+						},
+					},
+					descriptor.DefaultValue{Value: []uint16(nil)},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"PoisonedNetworks"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"poisonedAddresses"},
+						AssignableKind: descriptor.AssignmentFunction(func(i interface{}) (object interface{}, ok bool) {
+							return parseNetworks(i)
+						}),
+					},
+					descriptor.DefaultValue{Value: []*net.IPNet(nil)},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"DNS0x20"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"dns0x20"},
+						AssignableKind: descriptor.AssignableKinds{
+							descriptor.KindBool,
+							descriptor.ConvertibleKind{
+								Kind: descriptor.KindString,
+								ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+									str, ok := original.(string)
+									if !ok {
+										return
+									}
+									switch str {
+									case "true":
+										return true, true
+									case "false":
+										return false, true
+									default:
+										return
+									}
+								},
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: false},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"VerifyReply"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"verifyReply"},
+						AssignableKind: descriptor.AssignableKinds{
+							descriptor.KindBool,
+							descriptor.ConvertibleKind{
+								Kind: descriptor.KindString,
+								ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+									str, ok := original.(string)
+									if !ok {
+										return
+									}
+									switch str {
+									case "true":
+										return true, true
+									case "false":
+										return false, true
+									default:
+										return
+									}
+								},
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: true},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"OnReferral"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"onReferral"},
+						AssignableKind: descriptor.ConvertibleKind{
+							Kind: descriptor.KindString,
+							ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+								str, ok := original.(string)
+								if !ok {
+									return
+								}
+								switch str {
+								case OnReferralPass, OnReferralLog, OnReferralError:
+									return str, true
+								default:
+									return nil, false
+								}
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: OnReferralPass},
+				},
+			},
 		},
 	}); err != nil {
 		common.ErrOutput(err)
@@ -0,0 +1,132 @@
+package nameserver
+
+import (
+	"github.com/miekg/dns"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testKeyName = "key.example."
+	testSecret  = "c2VjcmV0LWtleS1mb3ItdGVzdGluZw=="
+)
+
+// startServer serves handler over UDP and TCP on the same loopback port, and
+// returns that port. A non-nil tsigSecret enables TSIG verification.
+func startServer(t *testing.T, handler dns.HandlerFunc, tsigSecret map[string]string) uint16 {
+	t.Helper()
+	packetConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener, err := net.Listen("tcp", packetConn.LocalAddr().String())
+	if err != nil {
+		_ = packetConn.Close()
+		t.Fatal(err)
+	}
+	for _, s := range []*dns.Server{
+		{PacketConn: packetConn, Handler: handler, TsigSecret: tsigSecret},
+		{Listener: listener, Handler: handler, TsigSecret: tsigSecret},
+	} {
+		started := make(chan struct{})
+		s.NotifyStartedFunc = func() { close(started) }
+		go func(s *dns.Server) { _ = s.ActivateAndServe() }(s)
+		<-started
+		t.Cleanup(func(s *dns.Server) func() {
+			return func() { _ = s.Shutdown() }
+		}(s))
+	}
+	return uint16(packetConn.LocalAddr().(*net.UDPAddr).Port)
+}
+
+func testNameServer(port uint16) *NameServer {
+	return &NameServer{
+		Address:      net.ParseIP("127.0.0.1"),
+		Port:         port,
+		Protocol:     "udp",
+		QueryTimeout: 2 * time.Second,
+		OnReferral:   OnReferralPass,
+	}
+}
+
+func TestTsigSignsEveryExchange(t *testing.T) {
+	var mutex sync.Mutex
+	signed := make(map[string]bool)
+	port := startServer(t, func(w dns.ResponseWriter, query *dns.Msg) {
+		network := w.RemoteAddr().Network()
+		tsig := query.IsTsig()
+		mutex.Lock()
+		signed[network] = tsig != nil && w.TsigStatus() == nil
+		mutex.Unlock()
+		reply := new(dns.Msg)
+		reply.SetReply(query)
+		if network == "udp" {
+			reply.Truncated = true
+		} else {
+			reply.Answer = append(reply.Answer, &dns.A{
+				Hdr: dns.RR_Header{Name: query.Question[0].Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60},
+				A:   net.ParseIP("192.0.2.1"),
+			})
+		}
+		if tsig != nil {
+			reply.SetTsig(tsig.Hdr.Name, tsig.Algorithm, 300, time.Now().Unix())
+		}
+		_ = w.WriteMsg(reply)
+	}, map[string]string{testKeyName: testSecret})
+	ns := testNameServer(port)
+	ns.TsigKeyName = testKeyName
+	ns.TsigAlgorithm = dns.HmacSHA256
+	ns.TsigSecret = testSecret
+	query := new(dns.Msg)
+	query.SetQuestion("example.com.", dns.TypeA)
+	reply, err := ns.Resolve(query, 1)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if len(reply.Answer) != 1 {
+		t.Fatalf("Resolve() answer = %v, want the answer sent over TCP", reply.Answer)
+	}
+	if query.IsTsig() != nil {
+		t.Error("Resolve() added a TSIG record to the original query")
+	}
+	for _, network := range []string{"udp", "tcp"} {
+		if !signed[network] {
+			t.Errorf("query over %s was not signed with a valid TSIG", network)
+		}
+	}
+}
+
+func TestVerifyReply(t *testing.T) {
+	mismatches := []struct {
+		name   string
+		mutate func(reply *dns.Msg)
+	}{
+		{"id", func(reply *dns.Msg) { reply.Id++ }},
+		{"qname", func(reply *dns.Msg) { reply.Question[0].Name = "other.example." }},
+		{"qtype", func(reply *dns.Msg) { reply.Question[0].Qtype = dns.TypeAAAA }},
+		{"qclass", func(reply *dns.Msg) { reply.Question[0].Qclass = dns.ClassCHAOS }},
+	}
+	for _, mismatch := range mismatches {
+		port := startServer(t, func(w dns.ResponseWriter, query *dns.Msg) {
+			reply := new(dns.Msg)
+			reply.SetReply(query)
+			mismatch.mutate(reply)
+			_ = w.WriteMsg(reply)
+		}, nil)
+		for _, verifyReply := range []bool{true, false} {
+			ns := testNameServer(port)
+			ns.VerifyReply = verifyReply
+			query := new(dns.Msg)
+			query.SetQuestion("example.com.", dns.TypeA)
+			reply, err := ns.Resolve(query, 1)
+			switch {
+			case verifyReply && err != ErrReplyMismatch:
+				t.Errorf("%s mismatch: Resolve() error = %v, want %v", mismatch.name, err, ErrReplyMismatch)
+			case !verifyReply && (err != nil || reply == nil):
+				t.Errorf("%s mismatch without verifyReply: Resolve() error = %v, want the reply", mismatch.name, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package limit
+
+import (
+	"github.com/miekg/dns"
+	"github.com/zhouchenh/go-descriptor"
+	"github.com/zhouchenh/secDNS/internal/common"
+	"github.com/zhouchenh/secDNS/pkg/upstream/resolver"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type NXDomainRateLimit struct {
+	Resolver        resolver.Resolver
+	Threshold       int
+	Window          time.Duration
+	MinParentLabels int
+	parents         map[string]*parentState
+	lastSweep       time.Time
+	mutex           sync.Mutex
+}
+
+type parentState struct {
+	windowStart  time.Time
+	count        int
+	blockedUntil time.Time
+	lastSeen     time.Time
+	existing     map[string]struct{}
+}
+
+// maxExistingNames limits the number of names remembered to exist under each
+// parent domain name.
+const maxExistingNames = 256
+
+var typeOfNXDomainRateLimit = descriptor.TypeOfNew(new(*NXDomainRateLimit))
+
+func (nl *NXDomainRateLimit) Type() descriptor.Type {
+	return typeOfNXDomainRateLimit
+}
+
+func (nl *NXDomainRateLimit) TypeName() string {
+	return "nxdomainRateLimit"
+}
+
+func (nl *NXDomainRateLimit) Resolve(query *dns.Msg, depth int) (*dns.Msg, error) {
+	if depth < 0 {
+		return nil, resolver.ErrLoopDetected
+	}
+	name := strings.ToLower(query.Question[0].Name)
+	// Parent domain names with fewer than MinParentLabels labels, such as top
+	// level domains, are never limited, since blocking them would affect many
+	// unrelated domain names.
+	if dns.CountLabel(name) <= nl.MinParentLabels {
+		return nl.Resolver.Resolve(query, depth-1)
+	}
+	parent := name[dns.Split(name)[1]:]
+	now := time.Now()
+	if nl.isBlocked(parent, name, now) {
+		return nl.blockedReply(query, parent), nil
+	}
+	reply, err := nl.Resolver.Resolve(query, depth-1)
+	if err != nil {
+		return nil, err
+	}
+	nl.record(parent, name, reply.Rcode, now)
+	return reply, nil
+}
+
+func (nl *NXDomainRateLimit) isBlocked(parent, name string, now time.Time) bool {
+	nl.mutex.Lock()
+	defer nl.mutex.Unlock()
+	state, ok := nl.parents[parent]
+	if !ok || !now.Before(state.blockedUntil) {
+		return false
+	}
+	state.lastSeen = now
+	_, exists := state.existing[name]
+	return !exists
+}
+
+// record counts NXDOMAIN replies for names under parent, and blocks further
+// queries for unknown names under parent for a window once the threshold is
+// reached within a window. Names replied without an error are remembered, so
+// that they are still forwarded while parent is blocked.
+func (nl *NXDomainRateLimit) record(parent, name string, rcode int, now time.Time) {
+	nl.mutex.Lock()
+	defer nl.mutex.Unlock()
+	nl.sweep(now)
+	state, ok := nl.parents[parent]
+	if !ok {
+		state = &parentState{windowStart: now, existing: make(map[string]struct{})}
+		nl.parents[parent] = state
+	}
+	state.lastSeen = now
+	switch rcode {
+	case dns.RcodeSuccess:
+		if len(state.existing) < maxExistingNames {
+			state.existing[name] = struct{}{}
+		}
+	case dns.RcodeNameError:
+		if now.Sub(state.windowStart) >= nl.Window {
+			state.windowStart, state.count = now, 0
+		}
+		state.count++
+		if state.count >= nl.Threshold {
+			state.blockedUntil = now.Add(nl.Window)
+			state.windowStart, state.count = now, 0
+		}
+	}
+}
+
+// sweep removes the states of parent domain names which have not been seen
+// within a window, at most once per window.
+func (nl *NXDomainRateLimit) sweep(now time.Time) {
+	if nl.parents == nil {
+		nl.parents = make(map[string]*parentState)
+	}
+	if now.Sub(nl.lastSweep) < nl.Window {
+		return
+	}
+	nl.lastSweep = now
+	for parent, state := range nl.parents {
+		if now.Sub(state.lastSeen) >= nl.Window && !now.Before(state.blockedUntil) {
+			delete(nl.parents, parent)
+		}
+	}
+}
+
+func (nl *NXDomainRateLimit) blockedReply(query *dns.Msg, parent string) *dns.Msg {
+	ttl := uint32(nl.Window / time.Second)
+	if ttl < 1 {
+		ttl = 1
+	}
+	msg := new(dns.Msg)
+	msg.SetRcode(query, dns.RcodeNameError)
+	msg.RecursionAvailable = true
+	msg.Ns = append(msg.Ns, common.SyntheticSOA(parent, ttl))
+	common.AddExtendedError(query, msg, dns.ExtendedErrorCodeBlocked, "NXDOMAIN flood")
+	return msg
+}
+
+func init() {
+	if err := resolver.RegisterResolver(&descriptor.Descriptor{
+		Type: typeOfNXDomainRateLimit,
+		Filler: descriptor.Fillers{
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"Resolver"},
+				ValueSource: descriptor.ObjectAtPath{
+					ObjectPath: descriptor.Path{"resolver"},
+					AssignableKind: descriptor.AssignmentFunction(func(i interface{}) (object interface{}, ok bool) {
+						object, s, f := resolver.Descriptor().Describe(i)
+						ok = s > 0 && f < 1
+						return
+					}),
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"Threshold"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"threshold"},
+						AssignableKind: descriptor.AssignableKinds{
+							descriptor.ConvertibleKind{
+								Kind: descriptor.KindFloat64,
+								ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+									num, ok := original.(float64)
+									if !ok || num < 1 || num > 1<<30 {
+										return nil, false
+									}
+									return int(num), true
+								},
+							},
+							descriptor.ConvertibleKind{
+								Kind: descriptor.KindString,
+								ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+									str, ok := original.(string)
+									if !ok {
+										return
+									}
+									i, err := strconv.Atoi(str)
+									if err != nil || i < 1 || i > 1<<30 {
+										return nil, false
+									}
+									return i, true
+								},
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: 100},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"Window"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"window"},
+						AssignableKind: descriptor.AssignableKinds{
+							descriptor.ConvertibleKind{
+								Kind: descriptor.KindFloat64,
+								ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+									num, ok := original.(float64)
+									if !ok || num <= 0 {
+										return nil, false
+									}
+									return time.Duration(num * float64(time.Second)), true
+								},
+							},
+							descriptor.ConvertibleKind{
+								Kind: descriptor.KindString,
+								ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+									str, ok := original.(string)
+									if !ok {
+										return
+									}
+									num, err := strconv.ParseFloat(str, 64)
+									if err != nil || num <= 0 {
+										return nil, false
+									}
+									return time.Duration(num * float64(time.Second)), true
+								},
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: 10 * time.Second},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"MinParentLabels"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"minParentLabels"},
+						AssignableKind: descriptor.AssignableKinds{
+							descriptor.ConvertibleKind{
+								Kind: descriptor.KindFloat64,
+								ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+									num, ok := original.(float64)
+									if !ok || num < 1 || num > 127 {
+										return nil, false
+									}
+									return int(num), true
+								},
+							},
+							descriptor.ConvertibleKind{
+								Kind: descriptor.KindString,
+								ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+									str, ok := original.(string)
+									if !ok {
+										return
+									}
+									i, err := strconv.Atoi(str)
+									if err != nil || i < 1 || i > 127 {
+										return nil, false
+									}
+									return i, true
+								},
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: 2},
+				},
+			},
+		},
+	}); err != nil {
+		common.ErrOutput(err)
+	}
+}
@@ -0,0 +1,79 @@
+package limit
+
+import (
+	"github.com/miekg/dns"
+	"github.com/zhouchenh/go-descriptor"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// existingNames replies with NXDOMAIN for every name not in the map, and
+// counts the queries it receives.
+type existingNames struct {
+	names   map[string]bool
+	queries int
+}
+
+func (e *existingNames) Type() descriptor.Type { return nil }
+func (e *existingNames) TypeName() string      { return "existingNames" }
+
+func (e *existingNames) Resolve(query *dns.Msg, depth int) (*dns.Msg, error) {
+	e.queries++
+	msg := new(dns.Msg)
+	msg.SetReply(query)
+	if !e.names[query.Question[0].Name] {
+		msg.Rcode = dns.RcodeNameError
+	}
+	return msg, nil
+}
+
+func resolve(t *testing.T, nl *NXDomainRateLimit, name string) *dns.Msg {
+	t.Helper()
+	query := new(dns.Msg)
+	query.SetQuestion(name, dns.TypeA)
+	reply, err := nl.Resolve(query, 2)
+	if err != nil {
+		t.Fatalf("Resolve(%s) error = %v", name, err)
+	}
+	return reply
+}
+
+func TestTopLevelDomainsAreNotLimited(t *testing.T) {
+	upstream := &existingNames{names: map[string]bool{"example.com.": true}}
+	nl := &NXDomainRateLimit{Resolver: upstream, Threshold: 3, Window: time.Minute, MinParentLabels: 2}
+	for i := 0; i < 10; i++ {
+		resolve(t, nl, "typo"+strconv.Itoa(i)+".com.")
+	}
+	queries := upstream.queries
+	if reply := resolve(t, nl, "example.com."); reply.Rcode != dns.RcodeSuccess || upstream.queries != queries+1 {
+		t.Errorf("example.com. was limited after NXDOMAIN errors for other names under com.")
+	}
+}
+
+func TestSubdomainsAreLimited(t *testing.T) {
+	upstream := &existingNames{names: map[string]bool{"www.example.com.": true}}
+	nl := &NXDomainRateLimit{Resolver: upstream, Threshold: 3, Window: time.Minute, MinParentLabels: 2}
+	resolve(t, nl, "www.example.com.")
+	for i := 0; i < 3; i++ {
+		resolve(t, nl, "x"+strconv.Itoa(i)+".example.com.")
+	}
+	queries := upstream.queries
+	if reply := resolve(t, nl, "x9.example.com."); reply.Rcode != dns.RcodeNameError || upstream.queries != queries {
+		t.Errorf("x9.example.com. was forwarded while example.com. is limited")
+	}
+	if reply := resolve(t, nl, "www.example.com."); reply.Rcode != dns.RcodeSuccess || upstream.queries != queries+1 {
+		t.Errorf("www.example.com. was not forwarded while example.com. is limited")
+	}
+}
+
+func TestMinParentLabels(t *testing.T) {
+	upstream := &existingNames{names: map[string]bool{"example.co.uk.": true}}
+	nl := &NXDomainRateLimit{Resolver: upstream, Threshold: 3, Window: time.Minute, MinParentLabels: 3}
+	for i := 0; i < 10; i++ {
+		resolve(t, nl, "typo"+strconv.Itoa(i)+".co.uk.")
+	}
+	if reply := resolve(t, nl, "example.co.uk."); reply.Rcode != dns.RcodeSuccess {
+		t.Errorf("example.co.uk. was limited with minParentLabels 3")
+	}
+}
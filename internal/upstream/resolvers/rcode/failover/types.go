@@ -0,0 +1,167 @@
+package failover
+
+import (
+	"github.com/miekg/dns"
+	"github.com/zhouchenh/go-descriptor"
+	"github.com/zhouchenh/secDNS/internal/common"
+	"github.com/zhouchenh/secDNS/pkg/upstream/resolver"
+	"strconv"
+	"strings"
+)
+
+type RcodeFailover struct {
+	Primary        resolver.Resolver
+	Secondary      resolver.Resolver
+	FailoverRcodes []int
+	MaxRetries     int
+}
+
+var typeOfRcodeFailover = descriptor.TypeOfNew(new(*RcodeFailover))
+
+func (rf *RcodeFailover) Type() descriptor.Type {
+	return typeOfRcodeFailover
+}
+
+func (rf *RcodeFailover) TypeName() string {
+	return "rcodeFailover"
+}
+
+func (rf *RcodeFailover) Resolve(query *dns.Msg, depth int) (*dns.Msg, error) {
+	if depth < 0 {
+		return nil, resolver.ErrLoopDetected
+	}
+	reply, err := rf.Primary.Resolve(query, depth-1)
+	if err == nil && reply != nil && !rf.isFailoverRcode(reply.Rcode) {
+		return reply, nil
+	}
+	var secondaryReply *dns.Msg
+	var secondaryErr error
+	for attempt := 0; attempt < rf.MaxRetries; attempt++ {
+		secondaryReply, secondaryErr = rf.Secondary.Resolve(query, depth-1)
+		if secondaryErr == nil && secondaryReply != nil && !rf.isFailoverRcode(secondaryReply.Rcode) {
+			return secondaryReply, nil
+		}
+	}
+	if secondaryErr != nil && err == nil && reply != nil {
+		return reply, nil
+	}
+	return secondaryReply, secondaryErr
+}
+
+func (rf *RcodeFailover) isFailoverRcode(rcode int) bool {
+	for _, failoverRcode := range rf.FailoverRcodes {
+		if rcode == failoverRcode {
+			return true
+		}
+	}
+	return false
+}
+
+func parseRcode(i interface{}) (rcode int, ok bool) {
+	switch value := i.(type) {
+	case string:
+		rcode, ok = dns.StringToRcode[strings.ToUpper(value)]
+	case float64:
+		if value >= 0 && value <= 4095 {
+			rcode, ok = int(value), true
+		}
+	}
+	return
+}
+
+func init() {
+	if err := resolver.RegisterResolver(&descriptor.Descriptor{
+		Type: typeOfRcodeFailover,
+		Filler: descriptor.Fillers{
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"Primary"},
+				ValueSource: descriptor.ObjectAtPath{
+					ObjectPath: descriptor.Path{"primary"},
+					AssignableKind: descriptor.AssignmentFunction(func(i interface{}) (object interface{}, ok bool) {
+						object, s, f := resolver.Descriptor().Describe(i)
+						ok = s > 0 && f < 1
+						return
+					}),
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"Secondary"},
+				ValueSource: descriptor.ObjectAtPath{
+					ObjectPath: descriptor.Path{"secondary"},
+					AssignableKind: descriptor.AssignmentFunction(func(i interface{}) (object interface{}, ok bool) {
+						object, s, f := resolver.Descriptor().Describe(i)
+						ok = s > 0 && f < 1
+						return
+					}),
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"FailoverRcodes"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"failoverRcodes"},
+						AssignableKind: descriptor.ConvertibleKind{
+							Kind: descriptor.KindSlice,
+							ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+								interfaces, ok := original.([]interface{})
+								if !ok {
+									return
+								}
+								var rcodes []int
+								for _, i := range interfaces {
+									rcode, ok := parseRcode(i)
+									if !ok {
+										return nil, false
+									}
+									rcodes = append(rcodes, rcode)
+								}
+								return rcodes, true
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: []int{dns.RcodeRefused, dns.RcodeServerFailure}},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"MaxRetries"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"maxRetries"},
+						AssignableKind: descriptor.AssignableKinds{
+							descriptor.ConvertibleKind{
+								Kind: descriptor.KindFloat64,
+								ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+									num, ok := original.(float64)
+									if !ok {
+										return
+									}
+									if num >= 1 && num <= 16 {
+										return int(num), true
+									}
+									return nil, false
+								},
+							},
+							descriptor.ConvertibleKind{
+								Kind: descriptor.KindString,
+								ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+									str, ok := original.(string)
+									if !ok {
+										return
+									}
+									i, err := strconv.Atoi(str)
+									if err != nil || i < 1 || i > 16 {
+										return nil, false
+									}
+									return i, true
+								},
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: 1},
+				},
+			},
+		},
+	}); err != nil {
+		common.ErrOutput(err)
+	}
+}
@@ -0,0 +1,219 @@
+package protection
+
+import (
+	"github.com/miekg/dns"
+	"github.com/zhouchenh/go-descriptor"
+	"github.com/zhouchenh/secDNS/internal/common"
+	"github.com/zhouchenh/secDNS/pkg/upstream/resolver"
+	"net"
+	"strings"
+)
+
+const (
+	ActionStrip  = "strip"
+	ActionRefuse = "refuse"
+)
+
+type RebindProtection struct {
+	Resolver        resolver.Resolver
+	PrivateNetworks []*net.IPNet
+	ExemptDomains   []string
+	Action          string
+}
+
+var typeOfRebindProtection = descriptor.TypeOfNew(new(*RebindProtection))
+
+var defaultPrivateNetworks = []string{
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"::/128",
+	"::1/128",
+	"fc00::/7",
+	"fe80::/10",
+}
+
+func (rp *RebindProtection) Type() descriptor.Type {
+	return typeOfRebindProtection
+}
+
+func (rp *RebindProtection) TypeName() string {
+	return "rebindProtection"
+}
+
+func (rp *RebindProtection) Resolve(query *dns.Msg, depth int) (*dns.Msg, error) {
+	if depth < 0 {
+		return nil, resolver.ErrLoopDetected
+	}
+	reply, err := rp.Resolver.Resolve(query, depth-1)
+	if err != nil {
+		return nil, err
+	}
+	if rp.isExempt(query.Question[0].Name) {
+		return reply, nil
+	}
+	notPrivate := func(rr dns.RR) bool {
+		switch record := rr.(type) {
+		case *dns.A:
+			return !rp.isPrivate(record.A)
+		case *dns.AAAA:
+			return !rp.isPrivate(record.AAAA)
+		default:
+			return true
+		}
+	}
+	answer := common.FilterResourceRecords(reply.Answer, notPrivate)
+	extra := common.FilterResourceRecords(reply.Extra, notPrivate)
+	if len(answer) == len(reply.Answer) && len(extra) == len(reply.Extra) {
+		return reply, nil
+	}
+	if rp.Action == ActionRefuse {
+		msg := new(dns.Msg)
+		msg.SetRcode(query, dns.RcodeRefused)
+		return msg, nil
+	}
+	reply.Answer = answer
+	reply.Extra = extra
+	return reply, nil
+}
+
+func (rp *RebindProtection) isPrivate(ip net.IP) bool {
+	for _, network := range rp.PrivateNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (rp *RebindProtection) isExempt(name string) bool {
+	name = strings.ToLower(name)
+	for _, domain := range rp.ExemptDomains {
+		if name == domain || strings.HasSuffix(name, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseNetworks(strs []string) (networks []*net.IPNet, ok bool) {
+	for _, str := range strs {
+		_, network, err := net.ParseCIDR(str)
+		if err != nil {
+			return nil, false
+		}
+		networks = append(networks, network)
+	}
+	return networks, true
+}
+
+func defaultNetworks() []*net.IPNet {
+	networks, _ := parseNetworks(defaultPrivateNetworks)
+	return networks
+}
+
+func stringSlice(original interface{}) (strs []string, ok bool) {
+	switch value := original.(type) {
+	case string:
+		return []string{value}, true
+	case []interface{}:
+		for _, i := range value {
+			str, ok := i.(string)
+			if !ok {
+				return nil, false
+			}
+			strs = append(strs, str)
+		}
+		return strs, true
+	default:
+		return nil, false
+	}
+}
+
+func init() {
+	if err := resolver.RegisterResolver(&descriptor.Descriptor{
+		Type: typeOfRebindProtection,
+		Filler: descriptor.Fillers{
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"Resolver"},
+				ValueSource: descriptor.ObjectAtPath{
+					ObjectPath: descriptor.Path{"resolver"},
+					AssignableKind: descriptor.AssignmentFunction(func(i interface{}) (object interface{}, ok bool) {
+						object, s, f := resolver.Descriptor().Describe(i)
+						ok = s > 0 && f < 1
+						return
+					}),
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"PrivateNetworks"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"privateNetworks"},
+						AssignableKind: descriptor.AssignmentFunction(func(i interface{}) (object interface{}, ok bool) {
+							strs, ok := stringSlice(i)
+							if !ok {
+								return
+							}
+							return parseNetworks(strs)
+						}),
+					},
+					descriptor.DefaultValue{Value: defaultNetworks()},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"ExemptDomains"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"exemptDomains"},
+						AssignableKind: descriptor.AssignmentFunction(func(i interface{}) (object interface{}, ok bool) {
+							strs, ok := stringSlice(i)
+							if !ok {
+								return
+							}
+							var domains []string
+							for _, str := range strs {
+								if _, ok := dns.IsDomainName(str); !ok {
+									return nil, false
+								}
+								domains = append(domains, strings.ToLower(dns.Fqdn(str)))
+							}
+							return domains, true
+						}),
+					},
+					descriptor.DefaultValue{Value: []string(nil)},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"Action"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"action"},
+						AssignableKind: descriptor.ConvertibleKind{
+							Kind: descriptor.KindString,
+							ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+								str, ok := original.(string)
+								if !ok {
+									return
+								}
+								switch str {
+								case ActionStrip, ActionRefuse:
+									return str, true
+								default:
+									return nil, false
+								}
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: ActionStrip},
+				},
+			},
+		},
+	}); err != nil {
+		common.ErrOutput(err)
+	}
+}
@@ -0,0 +1,74 @@
+package lookup
+
+import (
+	"github.com/miekg/dns"
+	"github.com/zhouchenh/go-descriptor"
+	"github.com/zhouchenh/secDNS/internal/common"
+	"github.com/zhouchenh/secDNS/pkg/upstream/resolver"
+	"strings"
+)
+
+type ReverseLookup struct {
+	ReverseResolver resolver.Resolver
+	DefaultResolver resolver.Resolver
+}
+
+var typeOfReverseLookup = descriptor.TypeOfNew(new(*ReverseLookup))
+
+func (rl *ReverseLookup) Type() descriptor.Type {
+	return typeOfReverseLookup
+}
+
+func (rl *ReverseLookup) TypeName() string {
+	return "reverseLookup"
+}
+
+func (rl *ReverseLookup) Resolve(query *dns.Msg, depth int) (*dns.Msg, error) {
+	if depth < 0 {
+		return nil, resolver.ErrLoopDetected
+	}
+	if isReverseQuery(query.Question[0]) {
+		return rl.ReverseResolver.Resolve(query, depth-1)
+	}
+	return rl.DefaultResolver.Resolve(query, depth-1)
+}
+
+func isReverseQuery(question dns.Question) bool {
+	if question.Qtype != dns.TypePTR {
+		return false
+	}
+	name := strings.ToLower(question.Name)
+	return strings.HasSuffix(name, ".in-addr.arpa.") || strings.HasSuffix(name, ".ip6.arpa.")
+}
+
+func init() {
+	if err := resolver.RegisterResolver(&descriptor.Descriptor{
+		Type: typeOfReverseLookup,
+		Filler: descriptor.Fillers{
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"ReverseResolver"},
+				ValueSource: descriptor.ObjectAtPath{
+					ObjectPath: descriptor.Path{"reverseResolver"},
+					AssignableKind: descriptor.AssignmentFunction(func(i interface{}) (object interface{}, ok bool) {
+						object, s, f := resolver.Descriptor().Describe(i)
+						ok = s > 0 && f < 1
+						return
+					}),
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"DefaultResolver"},
+				ValueSource: descriptor.ObjectAtPath{
+					ObjectPath: descriptor.Path{"defaultResolver"},
+					AssignableKind: descriptor.AssignmentFunction(func(i interface{}) (object interface{}, ok bool) {
+						object, s, f := resolver.Descriptor().Describe(i)
+						ok = s > 0 && f < 1
+						return
+					}),
+				},
+			},
+		},
+	}); err != nil {
+		common.ErrOutput(err)
+	}
+}
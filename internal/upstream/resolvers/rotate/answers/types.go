@@ -0,0 +1,79 @@
+package answers
+
+import (
+	"github.com/miekg/dns"
+	"github.com/zhouchenh/go-descriptor"
+	"github.com/zhouchenh/secDNS/internal/common"
+	"github.com/zhouchenh/secDNS/pkg/upstream/resolver"
+	"sync/atomic"
+)
+
+type RotateAnswers struct {
+	Resolver resolver.Resolver
+	counter  uint32
+}
+
+var typeOfRotateAnswers = descriptor.TypeOfNew(new(*RotateAnswers))
+
+func (ra *RotateAnswers) Type() descriptor.Type {
+	return typeOfRotateAnswers
+}
+
+func (ra *RotateAnswers) TypeName() string {
+	return "rotateAnswers"
+}
+
+func (ra *RotateAnswers) Resolve(query *dns.Msg, depth int) (*dns.Msg, error) {
+	if depth < 0 {
+		return nil, resolver.ErrLoopDetected
+	}
+	reply, err := ra.Resolver.Resolve(query, depth-1)
+	if err != nil {
+		return nil, err
+	}
+	counter := atomic.AddUint32(&ra.counter, 1)
+	rotate(reply.Answer, dns.TypeA, counter)
+	rotate(reply.Answer, dns.TypeAAAA, counter)
+	return reply, nil
+}
+
+func rotate(records []dns.RR, rrType uint16, counter uint32) {
+	var indexes []int
+	for i, record := range records {
+		if record.Header().Rrtype == rrType {
+			indexes = append(indexes, i)
+		}
+	}
+	if len(indexes) < 2 {
+		return
+	}
+	// Reduce the counter in unsigned arithmetic, as converting it to int
+	// directly overflows on 32-bit platforms.
+	offset := int(counter % uint32(len(indexes)))
+	rotated := make([]dns.RR, len(indexes))
+	for i, index := range indexes {
+		rotated[(i+offset)%len(indexes)] = records[index]
+	}
+	for i, index := range indexes {
+		records[index] = rotated[i]
+	}
+}
+
+func init() {
+	if err := resolver.RegisterResolver(&descriptor.Descriptor{
+		Type: typeOfRotateAnswers,
+		Filler: descriptor.ObjectFiller{
+			ObjectPath: descriptor.Path{"Resolver"},
+			ValueSource: descriptor.ObjectAtPath{
+				ObjectPath: descriptor.Root,
+				AssignableKind: descriptor.AssignmentFunction(func(i interface{}) (object interface{}, ok bool) {
+					object, s, f := resolver.Descriptor().Describe(i)
+					ok = s > 0 && f < 1
+					return
+				}),
+			},
+		},
+	}); err != nil {
+		common.ErrOutput(err)
+	}
+}
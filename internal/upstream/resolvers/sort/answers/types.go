@@ -0,0 +1,117 @@
+package answers
+
+import (
+	"github.com/miekg/dns"
+	"github.com/zhouchenh/go-descriptor"
+	"github.com/zhouchenh/secDNS/internal/common"
+	"github.com/zhouchenh/secDNS/pkg/upstream/resolver"
+)
+
+type SortAnswers struct {
+	Resolver     resolver.Resolver
+	PreferFamily string
+}
+
+const (
+	FamilyIPv4 = "ipv4"
+	FamilyIPv6 = "ipv6"
+)
+
+var typeOfSortAnswers = descriptor.TypeOfNew(new(*SortAnswers))
+
+func (sa *SortAnswers) Type() descriptor.Type {
+	return typeOfSortAnswers
+}
+
+func (sa *SortAnswers) TypeName() string {
+	return "sortAnswers"
+}
+
+func (sa *SortAnswers) Resolve(query *dns.Msg, depth int) (*dns.Msg, error) {
+	if depth < 0 {
+		return nil, resolver.ErrLoopDetected
+	}
+	reply, err := sa.Resolver.Resolve(query, depth-1)
+	if err != nil {
+		return nil, err
+	}
+	preferred, other := dns.TypeA, dns.TypeAAAA
+	if sa.PreferFamily == FamilyIPv6 {
+		preferred, other = dns.TypeAAAA, dns.TypeA
+	}
+	sortByType(reply.Answer, preferred, other)
+	return reply, nil
+}
+
+// sortByType stable-sorts the records of type first before the records of type
+// second. Only the positions held by records of either type are rearranged, so
+// that other records, such as CNAME and RRSIG, stay where they are. Records
+// which hold only one of the types, as replies to A or AAAA queries do, are
+// left unchanged.
+func sortByType(records []dns.RR, first, second uint16) {
+	var indexes []int
+	var firstRecords, secondRecords []dns.RR
+	for i, record := range records {
+		switch record.Header().Rrtype {
+		case first:
+			firstRecords = append(firstRecords, record)
+		case second:
+			secondRecords = append(secondRecords, record)
+		default:
+			continue
+		}
+		indexes = append(indexes, i)
+	}
+	if len(firstRecords) < 1 || len(secondRecords) < 1 {
+		return
+	}
+	sorted := append(firstRecords, secondRecords...)
+	for i, index := range indexes {
+		records[index] = sorted[i]
+	}
+}
+
+func init() {
+	if err := resolver.RegisterResolver(&descriptor.Descriptor{
+		Type: typeOfSortAnswers,
+		Filler: descriptor.Fillers{
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"Resolver"},
+				ValueSource: descriptor.ObjectAtPath{
+					ObjectPath: descriptor.Path{"resolver"},
+					AssignableKind: descriptor.AssignmentFunction(func(i interface{}) (object interface{}, ok bool) {
+						object, s, f := resolver.Descriptor().Describe(i)
+						ok = s > 0 && f < 1
+						return
+					}),
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"PreferFamily"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"preferFamily"},
+						AssignableKind: descriptor.ConvertibleKind{
+							Kind: descriptor.KindString,
+							ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+								str, ok := original.(string)
+								if !ok {
+									return
+								}
+								switch str {
+								case FamilyIPv4, FamilyIPv6:
+									return str, true
+								default:
+									return nil, false
+								}
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: FamilyIPv4},
+				},
+			},
+		},
+	}); err != nil {
+		common.ErrOutput(err)
+	}
+}
@@ -0,0 +1,9 @@
+package static
+
+var ErrNilResolver = NilPointerError("resolver")
+
+type NilPointerError string
+
+func (e NilPointerError) Error() string {
+	return "upstream/resolvers/static: Nil " + string(e)
+}
@@ -0,0 +1,185 @@
+package static
+
+import (
+	"github.com/miekg/dns"
+	"github.com/zhouchenh/go-descriptor"
+	"github.com/zhouchenh/secDNS/internal/common"
+	"github.com/zhouchenh/secDNS/pkg/upstream/resolver"
+	"net"
+	"strconv"
+	"strings"
+)
+
+const (
+	v4 = 0
+	v6 = 1
+)
+
+type Static struct {
+	Records  map[string][2][]net.IP
+	TTL      uint32
+	Resolver resolver.Resolver
+}
+
+var typeOfStatic = descriptor.TypeOfNew(new(*Static))
+
+func (s *Static) Type() descriptor.Type {
+	return typeOfStatic
+}
+
+func (s *Static) TypeName() string {
+	return "static"
+}
+
+func (s *Static) Resolve(query *dns.Msg, depth int) (*dns.Msg, error) {
+	if depth < 0 {
+		return nil, resolver.ErrLoopDetected
+	}
+	name := query.Question[0].Name
+	addr, ok := s.Records[strings.ToLower(name)]
+	if !ok {
+		if s.Resolver == nil {
+			return nil, ErrNilResolver
+		}
+		return s.Resolver.Resolve(query, depth-1)
+	}
+	msg := new(dns.Msg)
+	msg.SetReply(query)
+	switch query.Question[0].Qtype {
+	case dns.TypeA:
+		for _, ip := range addr[v4] {
+			msg.Answer = append(msg.Answer, &dns.A{
+				Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: s.TTL},
+				A:   ip,
+			})
+		}
+	case dns.TypeAAAA:
+		for _, ip := range addr[v6] {
+			msg.Answer = append(msg.Answer, &dns.AAAA{
+				Hdr:  dns.RR_Header{Name: name, Rrtype: dns.TypeAAAA, Class: dns.ClassINET, Ttl: s.TTL},
+				AAAA: ip,
+			})
+		}
+	}
+	if len(msg.Answer) < 1 {
+		msg.Ns = append(msg.Ns, common.SyntheticSOA(name, s.TTL))
+	}
+	return msg, nil
+}
+
+func parseAddress(i interface{}) (address [2][]net.IP, ok bool) {
+	var addresses []interface{}
+	switch value := i.(type) {
+	case string:
+		addresses = []interface{}{value}
+	case []interface{}:
+		addresses = value
+	default:
+		return
+	}
+	for _, a := range addresses {
+		str, ok := a.(string)
+		if !ok {
+			return address, false
+		}
+		ip := common.ParseIPv4v6(str)
+		switch len(ip) {
+		case net.IPv4len:
+			address[v4] = append(address[v4], ip)
+		case net.IPv6len:
+			address[v6] = append(address[v6], ip)
+		default:
+			return address, false
+		}
+	}
+	return address, true
+}
+
+func init() {
+	if err := resolver.RegisterResolver(&descriptor.Descriptor{
+		Type: typeOfStatic,
+		Filler: descriptor.Fillers{
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"Records"},
+				ValueSource: descriptor.ObjectAtPath{
+					ObjectPath: descriptor.Path{"records"},
+					AssignableKind: descriptor.ConvertibleKind{
+						Kind: descriptor.KindMap,
+						ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+							m, ok := original.(map[string]interface{})
+							if !ok {
+								return
+							}
+							records := make(map[string][2][]net.IP)
+							for name, i := range m {
+								if _, ok := dns.IsDomainName(name); !ok {
+									return nil, false
+								}
+								address, ok := parseAddress(i)
+								if !ok {
+									return nil, false
+								}
+								records[strings.ToLower(dns.Fqdn(name))] = address
+							}
+							return records, true
+						},
+					},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"TTL"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"ttl"},
+						AssignableKind: descriptor.AssignableKinds{
+							descriptor.ConvertibleKind{
+								Kind: descriptor.KindFloat64,
+								ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+									num, ok := original.(float64)
+									if !ok {
+										return
+									}
+									if num >= 0 && num <= 4294967295 {
+										return uint32(num), true
+									}
+									return nil, false
+								},
+							},
+							descriptor.ConvertibleKind{
+								Kind: descriptor.KindString,
+								ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+									str, ok := original.(string)
+									if !ok {
+										return
+									}
+									i, err := strconv.ParseUint(str, 10, 32)
+									if err != nil {
+										return nil, false
+									}
+									return uint32(i), true
+								},
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: uint32(60)},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"Resolver"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"resolver"},
+						AssignableKind: descriptor.AssignmentFunction(func(i interface{}) (object interface{}, ok bool) {
+							object, s, f := resolver.Descriptor().Describe(i)
+							ok = s > 0 && f < 1
+							return
+						}),
+					},
+					descriptor.DefaultValue{Value: nil},
+				},
+			},
+		},
+	}); err != nil {
+		common.ErrOutput(err)
+	}
+}
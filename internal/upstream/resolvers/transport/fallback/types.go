@@ -0,0 +1,143 @@
+package fallback
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"github.com/miekg/dns"
+	"github.com/zhouchenh/go-descriptor"
+	"github.com/zhouchenh/secDNS/internal/common"
+	"github.com/zhouchenh/secDNS/pkg/upstream/resolver"
+	"net"
+)
+
+const (
+	ErrorClassTimeout = "timeout"
+	ErrorClassNetwork = "network"
+	ErrorClassTLS     = "tls"
+	ErrorClassOther   = "other"
+)
+
+type TransportFallback struct {
+	Encrypted    resolver.Resolver
+	Plain        resolver.Resolver
+	ErrorClasses []string
+}
+
+var typeOfTransportFallback = descriptor.TypeOfNew(new(*TransportFallback))
+
+func (tf *TransportFallback) Type() descriptor.Type {
+	return typeOfTransportFallback
+}
+
+func (tf *TransportFallback) TypeName() string {
+	return "transportFallback"
+}
+
+func (tf *TransportFallback) Resolve(query *dns.Msg, depth int) (*dns.Msg, error) {
+	if depth < 0 {
+		return nil, resolver.ErrLoopDetected
+	}
+	reply, err := tf.Encrypted.Resolve(query, depth-1)
+	if err == nil || !tf.shouldFallback(err) {
+		return reply, err
+	}
+	return tf.Plain.Resolve(query, depth-1)
+}
+
+func (tf *TransportFallback) shouldFallback(err error) bool {
+	class := errorClass(err)
+	for _, c := range tf.ErrorClasses {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+func errorClass(err error) string {
+	var recordHeaderErr tls.RecordHeaderError
+	var certificateErr *tls.CertificateVerificationError
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var certificateInvalidErr x509.CertificateInvalidError
+	var alertErr tls.AlertError
+	switch {
+	case errors.As(err, &recordHeaderErr), errors.As(err, &certificateErr), errors.As(err, &unknownAuthorityErr),
+		errors.As(err, &hostnameErr), errors.As(err, &certificateInvalidErr), errors.As(err, &alertErr),
+		errors.Is(err, common.ErrSPKIPinMismatch):
+		return ErrorClassTLS
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return ErrorClassTimeout
+		}
+		return ErrorClassNetwork
+	}
+	return ErrorClassOther
+}
+
+func init() {
+	if err := resolver.RegisterResolver(&descriptor.Descriptor{
+		Type: typeOfTransportFallback,
+		Filler: descriptor.Fillers{
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"Encrypted"},
+				ValueSource: descriptor.ObjectAtPath{
+					ObjectPath: descriptor.Path{"encrypted"},
+					AssignableKind: descriptor.AssignmentFunction(func(i interface{}) (object interface{}, ok bool) {
+						object, s, f := resolver.Descriptor().Describe(i)
+						ok = s > 0 && f < 1
+						return
+					}),
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"Plain"},
+				ValueSource: descriptor.ObjectAtPath{
+					ObjectPath: descriptor.Path{"plain"},
+					AssignableKind: descriptor.AssignmentFunction(func(i interface{}) (object interface{}, ok bool) {
+						object, s, f := resolver.Descriptor().Describe(i)
+						ok = s > 0 && f < 1
+						return
+					}),
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"ErrorClasses"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"fallbackOn"},
+						AssignableKind: descriptor.ConvertibleKind{
+							Kind: descriptor.KindSlice,
+							ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+								interfaces, ok := original.([]interface{})
+								if !ok {
+									return
+								}
+								var classes []string
+								for _, i := range interfaces {
+									str, ok := i.(string)
+									if !ok {
+										return nil, false
+									}
+									switch str {
+									case ErrorClassTimeout, ErrorClassNetwork, ErrorClassTLS, ErrorClassOther:
+										classes = append(classes, str)
+									default:
+										return nil, false
+									}
+								}
+								return classes, true
+							},
+						},
+					},
+					descriptor.DefaultValue{Value: []string{ErrorClassTimeout, ErrorClassNetwork, ErrorClassTLS}},
+				},
+			},
+		},
+	}); err != nil {
+		common.ErrOutput(err)
+	}
+}
@@ -0,0 +1,23 @@
+package file
+
+import "errors"
+
+var (
+	ErrNilResolver = NilPointerError("resolver")
+	ErrMissingSOA  = errors.New("upstream/resolvers/zone/file: SOA record missing at zone apex")
+)
+
+type NilPointerError string
+
+func (e NilPointerError) Error() string {
+	return "upstream/resolvers/zone/file: Nil " + string(e)
+}
+
+type LoadZoneFileError struct {
+	filePath string
+	err      error
+}
+
+func (e LoadZoneFileError) Error() string {
+	return "upstream/resolvers/zone/file: Failed to load zone file \"" + e.filePath + "\" " + e.err.Error()
+}
@@ -0,0 +1,223 @@
+package file
+
+import (
+	"github.com/miekg/dns"
+	"github.com/zhouchenh/go-descriptor"
+	"github.com/zhouchenh/secDNS/internal/common"
+	"github.com/zhouchenh/secDNS/internal/core"
+	"github.com/zhouchenh/secDNS/pkg/upstream/resolver"
+	"strings"
+	"sync"
+)
+
+type ZoneFile struct {
+	FilePath string
+	Origin   string
+	Resolver resolver.Resolver
+	records  map[string][]dns.RR // keyed by lower-cased owner names
+	soa      *dns.SOA
+	// emptyNonTerminals holds the names between the zone apex and the owner
+	// names which own no records themselves.
+	emptyNonTerminals map[string]struct{}
+	loadOnce          sync.Once
+	loadErr           error
+}
+
+var typeOfZoneFile = descriptor.TypeOfNew(new(*ZoneFile))
+
+func (z *ZoneFile) Type() descriptor.Type {
+	return typeOfZoneFile
+}
+
+func (z *ZoneFile) TypeName() string {
+	return "zoneFile"
+}
+
+func (z *ZoneFile) Resolve(query *dns.Msg, depth int) (*dns.Msg, error) {
+	if depth < 0 {
+		return nil, resolver.ErrLoopDetected
+	}
+	if err := z.Load(); err != nil {
+		return nil, err
+	}
+	question := query.Question[0]
+	name := strings.ToLower(question.Name)
+	if !dns.IsSubDomain(z.Origin, name) {
+		if z.Resolver == nil {
+			return nil, ErrNilResolver
+		}
+		return z.Resolver.Resolve(query, depth-1)
+	}
+	msg := new(dns.Msg)
+	msg.SetReply(query)
+	if referral := z.delegation(name); referral != nil {
+		msg.Ns = referral
+		msg.Extra = z.glue(referral)
+		return msg, nil
+	}
+	msg.Authoritative = true
+	records, exists := z.records[name]
+	if !exists {
+		if !z.isEmptyNonTerminal(name) {
+			msg.Rcode = dns.RcodeNameError
+		}
+		msg.Ns = []dns.RR{common.NegativeSOA(z.soa)}
+		return msg, nil
+	}
+	for _, rr := range records {
+		if rrType := rr.Header().Rrtype; rrType == question.Qtype || question.Qtype == dns.TypeANY {
+			msg.Answer = append(msg.Answer, rr)
+		}
+	}
+	if len(msg.Answer) < 1 {
+		for _, rr := range records {
+			if rr.Header().Rrtype == dns.TypeCNAME {
+				msg.Answer = append(msg.Answer, rr)
+			}
+		}
+	}
+	if len(msg.Answer) < 1 {
+		msg.Ns = []dns.RR{common.NegativeSOA(z.soa)}
+	}
+	return msg, nil
+}
+
+// Load parses the zone file. It is called when the configuration is loaded, so
+// that a missing or malformed zone file is reported before serving queries.
+func (z *ZoneFile) Load() error {
+	z.loadOnce.Do(z.load)
+	return z.loadErr
+}
+
+func (z *ZoneFile) load() {
+	file, err := core.OpenFile(z.FilePath)
+	if err != nil {
+		z.loadErr = LoadZoneFileError{filePath: z.FilePath, err: err}
+		return
+	}
+	defer file.Close()
+	z.records = make(map[string][]dns.RR)
+	parser := dns.NewZoneParser(file, z.Origin, z.FilePath)
+	for rr, ok := parser.Next(); ok; rr, ok = parser.Next() {
+		name := strings.ToLower(rr.Header().Name)
+		z.records[name] = append(z.records[name], rr)
+		if soa, isSOA := rr.(*dns.SOA); isSOA && name == z.Origin {
+			z.soa = soa
+		}
+	}
+	if err := parser.Err(); err != nil {
+		z.loadErr = LoadZoneFileError{filePath: z.FilePath, err: err}
+		return
+	}
+	if z.soa == nil {
+		z.loadErr = LoadZoneFileError{filePath: z.FilePath, err: ErrMissingSOA}
+		return
+	}
+	z.emptyNonTerminals = make(map[string]struct{})
+	for owner := range z.records {
+		if !dns.IsSubDomain(z.Origin, owner) {
+			continue
+		}
+		for name := parentName(owner); name != "" && name != z.Origin; name = parentName(name) {
+			if _, exists := z.records[name]; exists {
+				continue
+			}
+			z.emptyNonTerminals[name] = struct{}{}
+		}
+	}
+}
+
+// parentName returns the name with its leftmost label removed, or an empty
+// string for the root.
+func parentName(name string) string {
+	offset, end := dns.NextLabel(name, 0)
+	if end {
+		return ""
+	}
+	return name[offset:]
+}
+
+// delegation returns the NS records of the closest zone cut between the zone
+// apex and the queried name, if any.
+func (z *ZoneFile) delegation(name string) (ns []dns.RR) {
+	labels := dns.SplitDomainName(name)
+	originLabels := dns.CountLabel(z.Origin)
+	for i := 0; i < len(labels)-originLabels; i++ {
+		for _, rr := range z.records[dns.Fqdn(strings.Join(labels[len(labels)-originLabels-i-1:], "."))] {
+			if rr.Header().Rrtype == dns.TypeNS {
+				ns = append(ns, rr)
+			}
+		}
+		if len(ns) > 0 {
+			return
+		}
+	}
+	return
+}
+
+func (z *ZoneFile) glue(ns []dns.RR) (extra []dns.RR) {
+	for _, rr := range ns {
+		for _, address := range z.records[strings.ToLower(rr.(*dns.NS).Ns)] {
+			switch address.Header().Rrtype {
+			case dns.TypeA, dns.TypeAAAA:
+				extra = append(extra, address)
+			}
+		}
+	}
+	return
+}
+
+func (z *ZoneFile) isEmptyNonTerminal(name string) bool {
+	_, exists := z.emptyNonTerminals[name]
+	return exists
+}
+
+func init() {
+	if err := resolver.RegisterResolver(&descriptor.Descriptor{
+		Type: typeOfZoneFile,
+		Filler: descriptor.Fillers{
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"FilePath"},
+				ValueSource: descriptor.ObjectAtPath{
+					ObjectPath:     descriptor.Path{"filePath"},
+					AssignableKind: descriptor.KindString,
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"Origin"},
+				ValueSource: descriptor.ObjectAtPath{
+					ObjectPath: descriptor.Path{"origin"},
+					AssignableKind: descriptor.ConvertibleKind{
+						Kind: descriptor.KindString,
+						ConvertFunction: func(original interface{}) (converted interface{}, ok bool) {
+							str, ok := original.(string)
+							if !ok {
+								return
+							}
+							if _, ok := dns.IsDomainName(str); !ok {
+								return nil, false
+							}
+							return strings.ToLower(dns.Fqdn(str)), true
+						},
+					},
+				},
+			},
+			descriptor.ObjectFiller{
+				ObjectPath: descriptor.Path{"Resolver"},
+				ValueSource: descriptor.ValueSources{
+					descriptor.ObjectAtPath{
+						ObjectPath: descriptor.Path{"resolver"},
+						AssignableKind: descriptor.AssignmentFunction(func(i interface{}) (object interface{}, ok bool) {
+							object, s, f := resolver.Descriptor().Describe(i)
+							ok = s > 0 && f < 1
+							return
+						}),
+					},
+					descriptor.DefaultValue{Value: nil},
+				},
+			},
+		},
+	}); err != nil {
+		common.ErrOutput(err)
+	}
+}
@@ -2,19 +2,26 @@ package main
 
 import (
 	"flag"
+	"github.com/miekg/dns"
 	"github.com/zhouchenh/secDNS/internal/common"
 	"github.com/zhouchenh/secDNS/internal/config"
 	"github.com/zhouchenh/secDNS/internal/core"
 	_ "github.com/zhouchenh/secDNS/internal/features"
+	"github.com/zhouchenh/secDNS/internal/logger"
 	"os"
 	"path/filepath"
 	"runtime"
+	"time"
 )
 
 var (
 	configFilePath = flag.String("config", "", "Specify a config file")
 	version        = flag.Bool("version", false, "Print version information and exit")
 	test           = flag.Bool("test", false, "Test the config file and exit")
+	selfTest       = flag.Bool("selftest", false, "Query a canary domain name through each resolver and report the results")
+	canary         = flag.String("canary", "example.com", "Specify the canary domain name used by -selftest")
+	errorRateLimit = flag.Int("errorratelimit", 0, "Log at most this many identical error messages per -errorrateinterval, 0 for no limit")
+	errorInterval  = flag.Duration("errorrateinterval", time.Minute, "Specify the interval used by -errorratelimit")
 )
 
 func printVersion() {
@@ -45,8 +52,25 @@ func open(filePath string) (*os.File, error) {
 	}
 }
 
+func reportSelfTestResult(result config.SelfTestResult) {
+	name := "default resolver"
+	if result.Name != "" {
+		name = "resolver \"" + result.Name + "\""
+	}
+	if result.Err != nil {
+		common.ErrOutput(common.Concatenate("selftest: ", name, " (", result.Resolver.TypeName(), ") failed after ", result.Duration.Round(time.Microsecond), ": ", result.Err))
+		return
+	}
+	if result.Rcode == dns.RcodeServerFailure || result.Rcode == dns.RcodeRefused {
+		common.ErrOutput(common.Concatenate("selftest: ", name, " (", result.Resolver.TypeName(), ") replied ", dns.RcodeToString[result.Rcode], " in ", result.Duration.Round(time.Microsecond)))
+		return
+	}
+	common.Output(common.Concatenate("selftest: ", name, " (", result.Resolver.TypeName(), ") replied ", dns.RcodeToString[result.Rcode], " in ", result.Duration.Round(time.Microsecond)))
+}
+
 func main() {
 	flag.Parse()
+	logger.SetErrorRateLimit(*errorRateLimit, *errorInterval)
 	printVersion()
 	if *version {
 		return
@@ -67,7 +91,7 @@ func main() {
 		os.Exit(1)
 	}
 	_ = os.Setenv(envConfigDirPath, filepath.Dir(file.Name()))
-	instance, err := config.LoadConfig(file)
+	instance, loadedConfig, err := config.LoadConfig(file)
 	_ = file.Close()
 	if err != nil {
 		common.ErrOutput(common.Concatenate("config: Failed to load config: ", err))
@@ -75,6 +99,11 @@ func main() {
 	}
 	if *test {
 		common.Output("config: Syntax is OK")
+	}
+	if *selfTest && !loadedConfig.SelfTest(*canary, reportSelfTestResult) && *test {
+		os.Exit(1)
+	}
+	if *test {
 		os.Exit(0)
 	}
 	runtime.GC()
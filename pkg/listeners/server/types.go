@@ -3,6 +3,7 @@ package server
 import (
 	"github.com/miekg/dns"
 	"github.com/zhouchenh/go-descriptor"
+	"net"
 )
 
 type Server interface {
@@ -11,6 +12,20 @@ type Server interface {
 	Serve(handler func(query *dns.Msg) (reply *dns.Msg), errorHandler func(err error))
 }
 
+type Bindable interface {
+	Server
+	Endpoints() []Endpoint
+}
+
+// Endpoint is a socket address a Bindable server listens on, along with the
+// socket options that decide whether other sockets may bind the same address.
+type Endpoint struct {
+	Addr      net.Addr
+	ReusePort bool
+	Interface string
+	V6Only    bool
+}
+
 var typeOfServer = descriptor.TypeOfNew(new(Server))
 
 func Type() descriptor.Type {
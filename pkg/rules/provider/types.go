@@ -11,6 +11,15 @@ type Provider interface {
 	Provide(receive func(name string, r resolver.Resolver), receiveError func(err error)) (more bool)
 }
 
+// Refreshable is implemented by providers whose rules may change after they
+// have been provided. The provider calls the handler set by SetRefreshHandler
+// when its rules have changed, after which Provide provides all of the new
+// rules from the beginning.
+type Refreshable interface {
+	Provider
+	SetRefreshHandler(handler func())
+}
+
 var typeOfProvider = descriptor.TypeOfNew(new(Provider))
 
 func Type() descriptor.Type {
@@ -6,7 +6,10 @@ func QueryCheck(query *dns.Msg) error {
 	if query == nil {
 		return ErrNilQuery
 	}
-	if len(query.Question) != 1 {
+	if len(query.Question) < 1 {
+		return ErrNoQuestion
+	}
+	if len(query.Question) > 1 {
 		return ErrTooManyQuestions
 	}
 	if query.Question[0].Qclass != dns.ClassINET {
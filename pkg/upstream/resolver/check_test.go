@@ -0,0 +1,30 @@
+package resolver
+
+import (
+	"github.com/miekg/dns"
+	"testing"
+)
+
+func TestQueryCheck(t *testing.T) {
+	question := dns.Question{Name: "example.com.", Qtype: dns.TypeA, Qclass: dns.ClassINET}
+	tests := []struct {
+		name      string
+		questions []dns.Question
+		want      error
+	}{
+		{"no questions", nil, ErrNoQuestion},
+		{"two questions", []dns.Question{question, question}, ErrTooManyQuestions},
+		{"chaos class", []dns.Question{{Name: "version.bind.", Qtype: dns.TypeTXT, Qclass: dns.ClassCHAOS}}, ErrNotSupportedQuestion},
+		{"one question", []dns.Question{question}, nil},
+	}
+	for _, test := range tests {
+		query := new(dns.Msg)
+		query.Question = test.questions
+		if err := QueryCheck(query); err != test.want {
+			t.Errorf("%s: QueryCheck() = %v, want %v", test.name, err, test.want)
+		}
+	}
+	if err := QueryCheck(nil); err != ErrNilQuery {
+		t.Errorf("QueryCheck(nil) = %v, want %v", err, ErrNilQuery)
+	}
+}
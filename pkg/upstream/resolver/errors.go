@@ -4,6 +4,7 @@ import "errors"
 
 var (
 	ErrNilQuery             = errors.New("upstream/resolver: Nil query")
+	ErrNoQuestion           = errors.New("upstream/resolver: No question")
 	ErrTooManyQuestions     = errors.New("upstream/resolver: Too many questions")
 	ErrNotSupportedQuestion = errors.New("upstream/resolver: Not supported question")
 	ErrLoopDetected         = errors.New("upstream/resolver: Possible endless loop detected")
@@ -0,0 +1,8 @@
+package resolver
+
+// Loader is implemented by resolvers which depend on external resources, such
+// as files, that should be loaded when the configuration is loaded rather than
+// on the first query.
+type Loader interface {
+	Load() error
+}